@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bobertlo/gmars/pkg/batch"
+	"github.com/bobertlo/gmars/pkg/cache"
+)
+
+// runBatch runs the batch subcommand with the arguments following "batch".
+// Jobs are read from a file, or from stdin if none is given or it is "-".
+func runBatch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: gmars batch [flags] [jobs.jsonl]\n")
+		fs.PrintDefaults()
+	}
+	jobs := fs.Int("j", 0, "Jobs to run in parallel (0 for all CPUs)")
+	outFlag := fs.String("o", "", "Write results to a file instead of stdout")
+	cacheFlag := fs.String("cache", "", "Reuse match results stored in a cache directory")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if err := batchJobs(fs.Arg(0), *outFlag, *cacheFlag, *jobs); err != nil {
+		fmt.Fprintf(os.Stderr, "batch: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// batchJobs runs the jobs read from inPath and writes the results to
+// outPath, using stdin and stdout for empty paths
+func batchJobs(inPath, outPath, cacheDir string, jobs int) error {
+	opts := batch.Options{Workers: jobs}
+	if cacheDir != "" {
+		c, err := cache.Open(cacheDir)
+		if err != nil {
+			return err
+		}
+		opts.Cache = c
+	}
+
+	var in io.Reader = os.Stdin
+	if inPath != "" && inPath != "-" {
+		f, err := os.Open(inPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if outPath == "" {
+		return batch.Run(context.Background(), in, os.Stdout, opts)
+	}
+	return writeFile(outPath, func(w io.Writer) error {
+		return batch.Run(context.Background(), in, w, opts)
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bobertlo/gmars/pkg/bench"
+	"github.com/bobertlo/gmars/pkg/cache"
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/bobertlo/gmars/pkg/score"
+)
+
+// benchOptions holds the flags of the bench subcommand
+type benchOptions struct {
+	preset    string
+	config    string
+	rounds    int
+	seed      int64
+	jobs      int
+	score     string
+	baseline  string
+	threshold float64
+	save      string
+	cache     string
+}
+
+// runBench runs the bench subcommand with the arguments following "bench".
+// It exits with status 2 if regressions from the baseline are found.
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: gmars bench [flags] warrior.red benchdir\n")
+		fs.PrintDefaults()
+	}
+	opts := benchOptions{}
+	fs.StringVar(&opts.preset, "preset", "94nop", "Use the settings of a named hill")
+	fs.StringVar(&opts.config, "config", "", "Load settings from a .json or .toml file")
+	fs.IntVar(&opts.rounds, "r", 100, "Rounds to play against each opponent")
+	fs.Int64Var(&opts.seed, "seed", 0, "Seed for warrior positions")
+	fs.IntVar(&opts.jobs, "j", 0, "Rounds to play in parallel (0 for all CPUs)")
+	fs.StringVar(&opts.score, "score", "", "Score formula (standard, multiwarrior or an expression of W, S, L and T)")
+	fs.StringVar(&opts.baseline, "baseline", "", "Compare with results saved by -save")
+	fs.Float64Var(&opts.threshold, "threshold", 5, "Score drop reported as a regression")
+	fs.StringVar(&opts.save, "save", "", "Save results as a baseline")
+	fs.StringVar(&opts.cache, "cache", "", "Reuse match results stored in a cache directory")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	regressions, err := benchWarrior(fs.Arg(0), fs.Arg(1), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bench: %s\n", err)
+		os.Exit(1)
+	}
+	if regressions {
+		os.Exit(2)
+	}
+}
+
+// benchWarrior runs a benchmark and prints the results, returning true if
+// regressions from the baseline were found
+func benchWarrior(wpath, dir string, opts benchOptions) (bool, error) {
+	var config mars.SimulatorConfig
+	var err error
+	if opts.config != "" {
+		config, err = mars.LoadConfigFile(opts.config)
+	} else {
+		config, err = mars.Preset(opts.preset)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	scorer, err := score.Parse(opts.score)
+	if err != nil {
+		return false, err
+	}
+
+	warrior, err := loadWarrior(wpath, config)
+	if err != nil {
+		return false, err
+	}
+	opponents, err := bench.LoadDir(dir, config)
+	if err != nil {
+		return false, err
+	}
+
+	var baseline *bench.Result
+	if opts.baseline != "" {
+		baseline, err = bench.LoadResult(opts.baseline)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	var resultCache *cache.Cache
+	if opts.cache != "" {
+		resultCache, err = cache.Open(opts.cache)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	result, err := bench.Run(context.Background(), bench.Bench{
+		Config:    config,
+		Warrior:   warrior,
+		Opponents: opponents,
+		Rounds:    opts.rounds,
+		Seed:      opts.seed,
+		Scorer:    scorer,
+		Workers:   opts.jobs,
+		Cache:     resultCache,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := result.WriteText(os.Stdout); err != nil {
+		return false, err
+	}
+
+	if opts.save != "" {
+		if err := result.Save(opts.save); err != nil {
+			return false, err
+		}
+	}
+
+	if baseline == nil {
+		return false, nil
+	}
+	comparison := bench.Compare(baseline, result, opts.threshold)
+	fmt.Println()
+	if err := bench.WriteChanges(os.Stdout, comparison); err != nil {
+		return false, err
+	}
+	return comparison.Regression(), nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bobertlo/gmars/pkg/hill"
+	"github.com/bobertlo/gmars/pkg/mars"
+)
+
+const hillUsage = `usage: gmars hill <command> [flags] hill.json [args]
+
+commands:
+  init       create an empty hill
+  challenge  play warriors against the hill and update it
+  report     print the hill standings
+  remove     remove a warrior from the hill by name
+`
+
+// runHill runs the hill subcommand with the arguments following "hill"
+func runHill(args []string) {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, hillUsage)
+		os.Exit(1)
+	}
+
+	var err error
+	switch args[0] {
+	case "init":
+		err = hillInit(args[1:])
+	case "challenge":
+		err = hillChallenge(args[1:])
+	case "report":
+		err = hillReport(args[1:])
+	case "remove":
+		err = hillRemove(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, hillUsage)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hill %s: %s\n", args[0], err)
+		os.Exit(1)
+	}
+}
+
+func hillInit(args []string) error {
+	fs := flag.NewFlagSet("hill init", flag.ExitOnError)
+	presetFlag := fs.String("preset", "94nop", "Use the settings of a named hill")
+	configFlag := fs.String("config", "", "Load settings from a .json or .toml file")
+	sizeFlag := fs.Int("size", 10, "Number of warriors on the hill")
+	roundFlag := fs.Int("r", 100, "Rounds played by each pairing")
+	seedFlag := fs.Int64("seed", 0, "Seed for warrior positions")
+	scoreFlag := fs.String("score", "", "Score formula (standard, multiwarrior or an expression of W, S, L and T)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected hill file")
+	}
+	path := fs.Arg(0)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("'%s' already exists", path)
+	}
+
+	var config mars.SimulatorConfig
+	var err error
+	if *configFlag != "" {
+		config, err = mars.LoadConfigFile(*configFlag)
+	} else {
+		config, err = mars.Preset(*presetFlag)
+	}
+	if err != nil {
+		return err
+	}
+
+	h, err := hill.New(hill.Settings{
+		Config:  config,
+		Size:    *sizeFlag,
+		Rounds:  *roundFlag,
+		Seed:    *seedFlag,
+		Scoring: *scoreFlag,
+	})
+	if err != nil {
+		return err
+	}
+	return h.Save(path)
+}
+
+func hillChallenge(args []string) error {
+	fs := flag.NewFlagSet("hill challenge", flag.ExitOnError)
+	jobsFlag := fs.Int("j", 0, "Rounds to play in parallel (0 for all CPUs)")
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		return fmt.Errorf("expected hill file and warrior files")
+	}
+
+	path := fs.Arg(0)
+	h, err := hill.Load(path)
+	if err != nil {
+		return err
+	}
+	h.Workers = *jobsFlag
+
+	for _, wpath := range fs.Args()[1:] {
+		data, err := loadWarrior(wpath, h.Settings.Config)
+		if err != nil {
+			return err
+		}
+		result, err := h.Challenge(context.Background(), data)
+		if err != nil {
+			return err
+		}
+		// save after each challenge so finished work is kept
+		if err := h.Save(path); err != nil {
+			return err
+		}
+		if err := h.WriteChallengeReport(os.Stdout, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hillReport(args []string) error {
+	fs := flag.NewFlagSet("hill report", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected hill file")
+	}
+
+	h, err := hill.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return h.WriteReport(os.Stdout)
+}
+
+func hillRemove(args []string) error {
+	fs := flag.NewFlagSet("hill remove", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return fmt.Errorf("expected hill file and warrior name")
+	}
+
+	path := fs.Arg(0)
+	h, err := hill.Load(path)
+	if err != nil {
+		return err
+	}
+	if !h.Remove(fs.Arg(1)) {
+		return fmt.Errorf("no warrior named '%s' on the hill", fs.Arg(1))
+	}
+	return h.Save(path)
+}
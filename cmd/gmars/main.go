@@ -1,15 +1,33 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"math/rand"
+	"io"
 	"os"
+	"time"
 
 	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/bobertlo/gmars/pkg/posmap"
+	"github.com/bobertlo/gmars/pkg/score"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "hill":
+			runHill(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		case "batch":
+			runBatch(os.Args[2:])
+			return
+		}
+	}
+
 	use88Flag := flag.Bool("8", false, "Enforce ICWS'88 rules")
 	sizeFlag := flag.Int("s", 8000, "Size of core")
 	procFlag := flag.Int("p", 8000, "Max. Processes")
@@ -17,7 +35,17 @@ func main() {
 	lenFlag := flag.Int("l", 100, "Max. warrior length")
 	fixedFlag := flag.Int("F", 0, "fixed position of warrior #2")
 	roundFlag := flag.Int("r", 1, "Rounds to play")
+	permuteFlag := flag.Bool("P", false, "Play warrior #2 at every starting position")
+	strideFlag := flag.Int("stride", 1, "Step between starting positions with -P")
+	positionsFlag := flag.Bool("positions", false, "Print the result at each starting position")
+	mapFlag := flag.String("map", "", "Write results by starting position of warrior #2 as CSV")
+	pngFlag := flag.String("png", "", "Write results by starting position of warrior #2 as a PNG strip")
+	jobsFlag := flag.Int("j", 0, "Rounds to play in parallel (0 for all CPUs)")
+	seedFlag := flag.Int64("seed", 0, "Seed for warrior positions (random if unset)")
+	scoreFlag := flag.String("score", "", "Print scores using a formula (standard, multiwarrior or an expression of W, S, L and T)")
 	debugFlag := flag.Bool("debug", false, "Dump verbose reporting of simulator state")
+	presetFlag := flag.String("preset", "", "Use the settings of a named hill")
+	configFlag := flag.String("config", "", "Load settings from a .json or .toml file")
 	flag.Parse()
 
 	coresize := mars.Address(*sizeFlag)
@@ -32,7 +60,42 @@ func main() {
 	} else {
 		mode = mars.ICWS94
 	}
-	config := mars.NewQuickConfig(mode, coresize, processes, cycles, length)
+	var config mars.SimulatorConfig
+	var err error
+	switch {
+	case *configFlag != "":
+		config, err = mars.LoadConfigFile(*configFlag)
+	case *presetFlag != "":
+		config, err = mars.Preset(*presetFlag)
+	default:
+		config = mars.NewQuickConfig(mode, coresize, processes, cycles, length)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %s\n", err)
+		os.Exit(1)
+	}
+
+	// flags given explicitly override the preset or config file
+	seedSet := false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			seedSet = true
+		case "8":
+			config.Mode = mode
+		case "s":
+			config.CoreSize = coresize
+			config.ReadLimit = coresize
+			config.WriteLimit = coresize
+		case "p":
+			config.Processes = processes
+		case "c":
+			config.Cycles = cycles
+		case "l":
+			config.Length = length
+			config.Distance = length
+		}
+	})
 
 	args := flag.Args()
 
@@ -66,65 +129,141 @@ func main() {
 	}
 	w1file.Close()
 
-	rounds := *roundFlag
+	scorer, err := score.Parse(*scoreFlag)
+	if err == nil {
+		err = score.Validate(scorer, 2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error parsing score formula: %s\n", err)
+		os.Exit(1)
+	}
+
+	match := mars.Match{
+		Config:   config,
+		Warriors: []*mars.WarriorData{&w1data, &w2data},
+		Rounds:   *roundFlag,
+		Seed:     *seedFlag,
+		Fixed:    mars.Address(*fixedFlag),
+		Permute:  *permuteFlag,
+		Stride:   mars.Address(*strideFlag),
+		Workers:  *jobsFlag,
 
-	w1win := 0
-	w1tie := 0
-	w2win := 0
-	w2tie := 0
-	for i := 0; i < rounds; i++ {
-		sim, err := mars.NewReportingSimulator(config)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error creating sim: %s", err)
-		}
-		if *debugFlag {
-			sim.AddReporter(mars.NewDebugReporter(sim))
-		}
+		RecordPositions: *positionsFlag || *mapFlag != "" || *pngFlag != "",
+	}
+	if !seedSet {
+		match.Seed = time.Now().UnixNano()
+	}
 
-		w2start := *fixedFlag
-		if w2start == 0 {
-			minStart := 2 * config.Length
-			maxStart := config.CoreSize - config.Length - 1
-			startRange := maxStart - minStart
-			w2start = rand.Intn(int(startRange)+1) + int(minStart)
-		}
+	var result mars.MatchResult
+	if *debugFlag {
+		result, err = runDebugMatch(match)
+	} else {
+		result, err = mars.RunMatch(context.Background(), match)
+	}
+	if err != nil {
+		fmt.Printf("error running match: %s\n", err)
+		os.Exit(1)
+	}
 
-		w1, err := sim.AddWarrior(&w1data)
-		if err != nil {
-			fmt.Printf("error adding warrior 1: %s", err)
-		}
-		err = sim.SpawnWarrior(0, 0)
-		if err != nil {
-			fmt.Printf("error adding warrior 1: %s", err)
+	if *scoreFlag == "" {
+		fmt.Printf("%d %d\n", result.Wins[0], result.Ties[0])
+		fmt.Printf("%d %d\n", result.Wins[1], result.Ties[1])
+	} else {
+		points := score.Match(scorer, result)
+		fmt.Printf("%d %d %g\n", result.Wins[0], result.Ties[0], points[0])
+		fmt.Printf("%d %d %g\n", result.Wins[1], result.Ties[1], points[1])
+	}
+
+	if *positionsFlag {
+		for _, p := range result.Positions {
+			outcome := "none"
+			switch {
+			case p.Tie:
+				outcome = "tie"
+			case p.Winner >= 0:
+				outcome = fmt.Sprintf("%d", p.Winner+1)
+			}
+			fmt.Printf("%d %s %d\n", p.Offset, outcome, p.Cycles)
 		}
+	}
 
-		w2, err := sim.AddWarrior(&w2data)
-		if err != nil {
-			fmt.Printf("error adding warrior 2: %s", err)
+	cells := posmap.Summarize(result.Positions)
+	if *mapFlag != "" {
+		if err := writeFile(*mapFlag, func(w io.Writer) error { return posmap.WriteCSV(w, cells) }); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing map: %s\n", err)
+			os.Exit(1)
 		}
-		err = sim.SpawnWarrior(1, mars.Address(w2start))
-		if err != nil {
-			fmt.Printf("error spawning warrior 1: %s", err)
+	}
+	if *pngFlag != "" {
+		if err := writeFile(*pngFlag, func(w io.Writer) error { return posmap.WritePNG(w, cells, 32) }); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing map: %s\n", err)
+			os.Exit(1)
 		}
+	}
+}
 
-		sim.Run()
+// writeFile creates a file and writes it with write
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
 
-		if w1.Alive() {
-			if w2.Alive() {
-				w1tie += 1
-			} else {
-				w1win += 1
-			}
+// runDebugMatch plays the rounds of a match one after another on a
+// reporting simulator with a debug reporter
+func runDebugMatch(m mars.Match) (mars.MatchResult, error) {
+	result := mars.NewMatchResult(len(m.Warriors))
+
+	sim, err := mars.NewReportingSimulator(m.Config)
+	if err != nil {
+		return result, err
+	}
+	sim.AddReporter(mars.NewDebugReporter(sim))
+	for i, data := range m.Warriors {
+		if _, err := sim.AddWarrior(data); err != nil {
+			return result, fmt.Errorf("error adding warrior %d: %s", i+1, err)
 		}
+	}
 
-		if w2.Alive() {
-			if w1.Alive() {
-				w2tie += 1
-			} else {
-				w2win += 1
-			}
+	offsets := make([]mars.Address, len(m.Warriors))
+	br := mars.BattleResult{}
+	for round := 0; round < m.RoundCount(); round++ {
+		if err := m.RoundOffsets(round, offsets); err != nil {
+			return result, err
 		}
+		if err := sim.RunRound(offsets, &br); err != nil {
+			return result, err
+		}
+		result.Add(&br)
+		if m.Permute || m.RecordPositions {
+			result.Positions = append(result.Positions, mars.PositionResult{
+				Offset: offsets[1],
+				Winner: br.Winner,
+				Tie:    br.Tie,
+				Cycles: br.Cycles,
+			})
+		}
+	}
+	return result, nil
+}
+
+// loadWarrior parses a warrior load file
+func loadWarrior(path string, config mars.SimulatorConfig) (*mars.WarriorData, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening warrior file '%s': %s", path, err)
+	}
+	defer f.Close()
+
+	data, err := mars.ParseLoadFile(f, config)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing warrior file '%s': %s", path, err)
 	}
-	fmt.Printf("%d %d\n", w1win, w1tie)
-	fmt.Printf("%d %d\n", w2win, w2tie)
+	return &data, nil
 }
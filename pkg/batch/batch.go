@@ -0,0 +1,299 @@
+// Package batch runs matches described by JSON Lines input and writes one
+// JSON result per line, for driving the simulator from pipelines.
+//
+// Each input line is a Job:
+//
+//	{"id": "a", "warriors": [{"path": "imp.red"}, {"code": "JMP.B $ 0, $ 0\n"}], "preset": "94nop", "rounds": 100, "seed": 1}
+//
+// A job that cannot be run produces a result with an error instead of
+// stopping the batch.
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/bobertlo/gmars/pkg/cache"
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/bobertlo/gmars/pkg/score"
+)
+
+// maxLineSize is the longest input line accepted, large enough for jobs
+// with inline code
+const maxLineSize = 16 << 20
+
+// Warrior is a warrior of a job, read from a load file or given inline
+type Warrior struct {
+	Path string `json:"path,omitempty"`
+	Code string `json:"code,omitempty"`
+}
+
+// Job describes a match
+type Job struct {
+	// ID is copied to the result to identify the job
+	ID string `json:"id,omitempty"`
+
+	Warriors []Warrior `json:"warriors"`
+
+	// Preset names the settings of a hill. Config is either the path of a
+	// .json or .toml config file or an inline JSON config. If neither is
+	// given the 94nop preset is used.
+	Preset string          `json:"preset,omitempty"`
+	Config json.RawMessage `json:"config,omitempty"`
+
+	// Rounds defaults to 1. Seed defaults to 0 so results are repeatable.
+	Rounds int   `json:"rounds,omitempty"`
+	Seed   int64 `json:"seed,omitempty"`
+
+	// Fixed, Permute and Stride place the second warrior as in mars.Match
+	Fixed   mars.Address `json:"fixed,omitempty"`
+	Permute bool         `json:"permute,omitempty"`
+	Stride  mars.Address `json:"stride,omitempty"`
+
+	// Score is a formula accepted by score.Parse, or empty for standard
+	// scoring
+	Score string `json:"score,omitempty"`
+}
+
+// Result is the output of a job
+type Result struct {
+	// Line is the input line of the job, starting from 1
+	Line int    `json:"line"`
+	ID   string `json:"id,omitempty"`
+
+	Names  []string          `json:"names,omitempty"`
+	Match  *mars.MatchResult `json:"result,omitempty"`
+	Scores []float64         `json:"scores,omitempty"`
+
+	// Cached is set if the result was read from the cache
+	Cached bool `json:"cached,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Options control how a batch is run
+type Options struct {
+	// Workers is the number of jobs run in parallel, or 0 to use
+	// GOMAXPROCS. Each job runs its rounds serially.
+	Workers int
+
+	// Cache stores the results of jobs already run, or nil to run every
+	// job
+	Cache *cache.Cache
+}
+
+// config returns the simulator config of a job
+func (j *Job) config() (mars.SimulatorConfig, error) {
+	if j.Preset != "" && len(j.Config) > 0 {
+		return mars.SimulatorConfig{}, fmt.Errorf("preset and config are exclusive")
+	}
+	if len(j.Config) == 0 {
+		if j.Preset == "" {
+			return mars.Preset("94nop")
+		}
+		return mars.Preset(j.Preset)
+	}
+
+	var path string
+	if err := json.Unmarshal(j.Config, &path); err == nil {
+		return mars.LoadConfigFile(path)
+	}
+	config, err := mars.LoadConfig(bytes.NewReader(j.Config), mars.ConfigJSON)
+	if err != nil {
+		return mars.SimulatorConfig{}, fmt.Errorf("invalid config: %s", err)
+	}
+	return config, nil
+}
+
+// load parses the code of a warrior
+func (w *Warrior) load(config mars.SimulatorConfig) (*mars.WarriorData, error) {
+	if (w.Path == "") == (w.Code == "") {
+		return nil, fmt.Errorf("warrior needs either a path or code")
+	}
+	if w.Code != "" {
+		data, err := mars.ParseLoadFile(strings.NewReader(w.Code), config)
+		if err != nil {
+			return nil, err
+		}
+		return &data, nil
+	}
+
+	f, err := os.Open(w.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := mars.ParseLoadFile(f, config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", w.Path, err)
+	}
+	return &data, nil
+}
+
+// Match returns the match described by a job
+func (j *Job) Match() (mars.Match, error) {
+	if len(j.Warriors) == 0 {
+		return mars.Match{}, fmt.Errorf("no warriors in job")
+	}
+	config, err := j.config()
+	if err != nil {
+		return mars.Match{}, err
+	}
+
+	warriors := make([]*mars.WarriorData, len(j.Warriors))
+	for i := range j.Warriors {
+		warriors[i], err = j.Warriors[i].load(config)
+		if err != nil {
+			return mars.Match{}, fmt.Errorf("warrior %d: %s", i+1, err)
+		}
+	}
+
+	rounds := j.Rounds
+	if rounds == 0 {
+		rounds = 1
+	}
+	return mars.Match{
+		Config:   config,
+		Warriors: warriors,
+		Rounds:   rounds,
+		Seed:     j.Seed,
+		Fixed:    j.Fixed,
+		Permute:  j.Permute,
+		Stride:   j.Stride,
+		Workers:  1,
+	}, nil
+}
+
+// RunJob runs a job, reporting any error in the result
+func RunJob(ctx context.Context, job *Job, c *cache.Cache) Result {
+	result := Result{ID: job.ID}
+	fail := func(err error) Result {
+		result.Error = err.Error()
+		return result
+	}
+
+	scorer, err := score.Parse(job.Score)
+	if err != nil {
+		return fail(err)
+	}
+	match, err := job.Match()
+	if err != nil {
+		return fail(err)
+	}
+	if err := score.Validate(scorer, len(match.Warriors)); err != nil {
+		return fail(err)
+	}
+	for _, data := range match.Warriors {
+		result.Names = append(result.Names, data.Name)
+	}
+
+	mr, cached, err := cache.RunMatch(ctx, c, match)
+	if err != nil {
+		return fail(err)
+	}
+	result.Match = &mr
+	result.Scores = score.Match(scorer, mr)
+	result.Cached = cached
+	return result
+}
+
+// runLine decodes and runs the job on an input line
+func runLine(ctx context.Context, line int, text []byte, c *cache.Cache) Result {
+	job := &Job{}
+	dec := json.NewDecoder(bytes.NewReader(text))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(job); err != nil {
+		return Result{Line: line, Error: fmt.Sprintf("invalid job: %s", err)}
+	}
+	result := RunJob(ctx, job, c)
+	result.Line = line
+	return result
+}
+
+// task is an input line waiting for a worker
+type task struct {
+	line int
+	text []byte
+	out  chan Result
+}
+
+// Run reads jobs from r, one per line, runs them in parallel and writes
+// their results to w in input order. Blank lines are skipped. Errors in
+// jobs are written as results; the returned error is only set if reading
+// the input or writing the output failed.
+func Run(ctx context.Context, r io.Reader, w io.Writer, opts Options) error {
+	workers := opts.Workers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers < 0 {
+		return fmt.Errorf("invalid worker count %d", workers)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tasks := make(chan task)
+	pending := make(chan chan Result, 2*workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for t := range tasks {
+				t.out <- runLine(ctx, t.line, t.text, opts.Cache)
+			}
+		}()
+	}
+
+	// read lines in order, queueing a result channel for each
+	var readErr error
+	go func() {
+		defer close(pending)
+		defer close(tasks)
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, maxLineSize)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := bytes.TrimSpace(scanner.Bytes())
+			if len(text) == 0 {
+				continue
+			}
+			t := task{line: line, text: append([]byte{}, text...), out: make(chan Result, 1)}
+			select {
+			case tasks <- t:
+			case <-ctx.Done():
+				return
+			}
+			pending <- t.out
+		}
+		readErr = scanner.Err()
+	}()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	var writeErr error
+	for out := range pending {
+		result := <-out
+		if writeErr != nil {
+			continue
+		}
+		if writeErr = enc.Encode(result); writeErr == nil {
+			writeErr = bw.Flush()
+		}
+		if writeErr != nil {
+			cancel()
+		}
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+	return readErr
+}
@@ -0,0 +1,127 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bobertlo/gmars/pkg/cache"
+	"github.com/bobertlo/gmars/pkg/internal/testwarriors"
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/stretchr/testify/require"
+)
+
+func jobLine(t *testing.T, job Job) string {
+	text, err := json.Marshal(job)
+	require.NoError(t, err)
+	return string(text)
+}
+
+func readResults(t *testing.T, out string) []Result {
+	var results []Result
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var r Result
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	impPath := filepath.Join(dir, "imp.red")
+	require.NoError(t, os.WriteFile(impPath, []byte(testwarriors.Imp), 0o644))
+	configPath := filepath.Join(dir, "tiny.json")
+	tiny, err := mars.Preset("tiny")
+	require.NoError(t, err)
+	require.NoError(t, mars.SaveConfigFile(configPath, tiny))
+
+	warriors := []Warrior{{Code: testwarriors.Dwarf}, {Path: impPath}}
+	input := strings.Join([]string{
+		jobLine(t, Job{ID: "preset", Warriors: warriors, Preset: "nano", Rounds: 10, Seed: 3}),
+		"",
+		jobLine(t, Job{ID: "file", Warriors: warriors, Config: json.RawMessage(fmt.Sprintf("%q", configPath)), Rounds: 5}),
+		`{"id": "inline", "warriors": [{"code": "JMP.B $ 0, $ 0\n"}], "config": {"mode": "nop94", "core_size": 80, "processes": 80, "cycles": 800, "length": 5, "distance": 5}}`,
+		`{"id": "broken"`,
+		jobLine(t, Job{ID: "missing", Warriors: []Warrior{{Path: filepath.Join(dir, "missing.red")}}}),
+		jobLine(t, Job{ID: "fixed", Warriors: warriors, Rounds: 3, Fixed: 4000, Score: "multiwarrior"}),
+		jobLine(t, Job{ID: "permute", Warriors: warriors, Preset: "nano", Permute: true, Stride: 10}),
+	}, "\n")
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Run(context.Background(), strings.NewReader(input), out, Options{Workers: 3}))
+	results := readResults(t, out.String())
+	require.Len(t, results, 7)
+
+	lines := []int{1, 3, 4, 5, 6, 7, 8}
+	for i, r := range results {
+		require.Equal(t, lines[i], r.Line)
+	}
+
+	require.Equal(t, "preset", results[0].ID)
+	require.Empty(t, results[0].Error)
+	require.Equal(t, []string{"Dwarf", "Imp"}, results[0].Names)
+	require.Equal(t, 10, results[0].Match.Rounds)
+	require.Len(t, results[0].Scores, 2)
+
+	require.Empty(t, results[1].Error)
+	require.Equal(t, 5, results[1].Match.Rounds)
+
+	require.Empty(t, results[2].Error)
+	require.Equal(t, 1, results[2].Match.Rounds)
+
+	require.Contains(t, results[3].Error, "invalid job")
+	require.Equal(t, "missing", results[4].ID)
+	require.NotEmpty(t, results[4].Error)
+	require.Nil(t, results[4].Match)
+
+	require.Empty(t, results[5].Error)
+	require.Equal(t, 3, results[5].Match.Rounds)
+
+	require.Empty(t, results[6].Error)
+	require.Len(t, results[6].Match.Positions, results[6].Match.Rounds)
+
+	// results do not depend on parallelism
+	serial := &bytes.Buffer{}
+	require.NoError(t, Run(context.Background(), strings.NewReader(input), serial, Options{Workers: 1}))
+	require.Equal(t, out.String(), serial.String())
+}
+
+func TestRunJobErrors(t *testing.T) {
+	warriors := []Warrior{{Code: testwarriors.Imp}, {Code: testwarriors.Imp}}
+	jobs := []Job{
+		{},
+		{Warriors: []Warrior{{}}},
+		{Warriors: []Warrior{{Code: testwarriors.Imp, Path: "imp.red"}}},
+		{Warriors: warriors, Preset: "nosuchhill"},
+		{Warriors: warriors, Preset: "nano", Config: json.RawMessage(`"x.json"`)},
+		{Warriors: warriors, Config: json.RawMessage(`{"core_size": -1}`)},
+		{Warriors: warriors, Score: "W+"},
+		{Warriors: []Warrior{{Code: "FOO.I $ 0, $ 0\n"}}},
+		{Warriors: warriors, Fixed: 9000},
+	}
+	for i := range jobs {
+		r := RunJob(context.Background(), &jobs[i], nil)
+		require.NotEmpty(t, r.Error, "job %d", i)
+		require.Nil(t, r.Match)
+	}
+}
+
+func TestRunCache(t *testing.T) {
+	c, err := cache.Open(t.TempDir())
+	require.NoError(t, err)
+
+	job := Job{Warriors: []Warrior{{Code: testwarriors.Dwarf}, {Code: testwarriors.Imp}}, Preset: "nano", Rounds: 10}
+	first := RunJob(context.Background(), &job, c)
+	require.Empty(t, first.Error)
+	require.False(t, first.Cached)
+
+	second := RunJob(context.Background(), &job, c)
+	require.True(t, second.Cached)
+	require.Equal(t, first.Match, second.Match)
+}
@@ -0,0 +1,310 @@
+// Package bench scores a warrior against a fixed set of opponents, such as
+// the Wilkies or WilFiz benchmarks, and compares the results with a saved
+// baseline.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bobertlo/gmars/pkg/cache"
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/bobertlo/gmars/pkg/score"
+)
+
+// Opponent is a warrior in a benchmark set
+type Opponent struct {
+	// Name identifies the opponent in results, usually its file name
+	Name string
+	Data *mars.WarriorData
+}
+
+// LoadDir parses every .red and .rc file in a directory as an opponent,
+// ordered by file name
+func LoadDir(dir string, config mars.SimulatorConfig) ([]Opponent, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Opponent
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".red" && ext != ".rc") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := mars.ParseLoadFile(f, config)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		out = append(out, Opponent{Name: entry.Name(), Data: &data})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no warriors found in '%s'", dir)
+	}
+	return out, nil
+}
+
+// Bench describes a benchmark run of a warrior against a set of opponents
+type Bench struct {
+	Config    mars.SimulatorConfig
+	Warrior   *mars.WarriorData
+	Opponents []Opponent
+	Rounds    int
+
+	// Seed selects the starting positions. Each opponent uses a seed derived
+	// from Seed and its name, so results against an opponent do not change
+	// when other opponents are added or removed.
+	Seed int64
+
+	// Scorer awards points for each round, or nil for score.Standard
+	Scorer score.Scorer
+
+	// Workers is the number of simulators run in parallel, or 0 to use
+	// GOMAXPROCS. Opponents are played in parallel, sharing the workers.
+	Workers int
+
+	// Cache stores the results of opponents already played, or nil to play
+	// every opponent
+	Cache *cache.Cache
+}
+
+// OpponentResult holds the results of the warrior against one opponent
+type OpponentResult struct {
+	Name   string `json:"name"`
+	Wins   int    `json:"wins"`
+	Losses int    `json:"losses"`
+	Ties   int    `json:"ties"`
+
+	// Score is the points earned per 100 rounds
+	Score float64 `json:"score"`
+}
+
+// Result holds the results of a benchmark run
+type Result struct {
+	Warrior   string           `json:"warrior"`
+	Rounds    int              `json:"rounds"`
+	Opponents []OpponentResult `json:"opponents"`
+
+	// Score is the average score against all opponents
+	Score float64 `json:"score"`
+}
+
+// opponentSeed returns the match seed used against an opponent
+func (b *Bench) opponentSeed(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return b.Seed ^ int64(h.Sum64())
+}
+
+// Run plays the warrior against each opponent. The results do not depend
+// on the number of workers.
+func Run(ctx context.Context, b Bench) (*Result, error) {
+	if b.Warrior == nil {
+		return nil, fmt.Errorf("no warrior to benchmark")
+	}
+	if len(b.Opponents) == 0 {
+		return nil, fmt.Errorf("no opponents in benchmark")
+	}
+	scorer := b.Scorer
+	if scorer == nil {
+		scorer = score.Standard
+	}
+	if err := score.Validate(scorer, 2); err != nil {
+		return nil, err
+	}
+
+	workers := b.Workers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers < 0 {
+		return nil, fmt.Errorf("invalid worker count %d", workers)
+	}
+
+	// split the workers between the matches running at once
+	parallel := min(workers, len(b.Opponents))
+	matchWorkers := max(workers/len(b.Opponents), 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]mars.MatchResult, len(b.Opponents))
+	errs := make([]error, len(b.Opponents))
+	next := make(chan int)
+	go func() {
+		defer close(next)
+		for i := range b.Opponents {
+			select {
+			case next <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				match := mars.Match{
+					Config:   b.Config,
+					Warriors: []*mars.WarriorData{b.Warrior, b.Opponents[i].Data},
+					Rounds:   b.Rounds,
+					Seed:     b.opponentSeed(b.Opponents[i].Name),
+					Workers:  matchWorkers,
+				}
+				results[i], _, errs[i] = cache.RunMatch(ctx, b.Cache, match)
+				if errs[i] != nil {
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// report the first opponent that failed, rather than the opponents
+	// canceled because of it
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, fmt.Errorf("%s: %s", b.Opponents[i].Name, err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Warrior:   b.Warrior.Name,
+		Rounds:    b.Rounds,
+		Opponents: make([]OpponentResult, len(b.Opponents)),
+	}
+	for i, opp := range b.Opponents {
+		mr := results[i]
+		points := score.Match(scorer, mr)
+		result.Opponents[i] = OpponentResult{
+			Name:   opp.Name,
+			Wins:   mr.Wins[0],
+			Losses: mr.Losses[0],
+			Ties:   mr.Ties[0],
+			Score:  score.PerRound(points[0], mr.Rounds),
+		}
+		result.Score += result.Opponents[i].Score
+	}
+	result.Score /= float64(len(result.Opponents))
+
+	return result, nil
+}
+
+// LoadResult reads a result saved by Save
+func LoadResult(path string) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := &Result{}
+	if err := json.NewDecoder(f).Decode(r); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return r, nil
+}
+
+// Save writes a result as JSON to use as a baseline
+func (r *Result) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Change is the difference in score against one opponent between a
+// baseline and a new result
+type Change struct {
+	Name     string
+	Baseline float64
+	Score    float64
+
+	// Regression is true if the score dropped by more than the threshold
+	Regression bool
+}
+
+// Delta returns the change in score
+func (c Change) Delta() float64 {
+	return c.Score - c.Baseline
+}
+
+// Comparison holds the changes in score between a baseline and a new
+// result
+type Comparison struct {
+	// Opponents holds the change against each opponent present in both
+	// results, ordered by name
+	Opponents []Change
+
+	// Overall is the change in the overall score. Its Name is empty.
+	Overall Change
+}
+
+// Regression returns true if any score dropped by more than the threshold
+func (c *Comparison) Regression() bool {
+	for _, o := range c.Opponents {
+		if o.Regression {
+			return true
+		}
+	}
+	return c.Overall.Regression
+}
+
+// Compare returns the score changes between a baseline and a new result. A
+// drop in score of more than threshold points is flagged as a regression.
+func Compare(baseline, current *Result, threshold float64) Comparison {
+	base := make(map[string]float64, len(baseline.Opponents))
+	for _, o := range baseline.Opponents {
+		base[o.Name] = o.Score
+	}
+
+	var out Comparison
+	for _, o := range current.Opponents {
+		b, ok := base[o.Name]
+		if !ok {
+			continue
+		}
+		out.Opponents = append(out.Opponents, Change{Name: o.Name, Baseline: b, Score: o.Score, Regression: b-o.Score > threshold})
+	}
+	sort.Slice(out.Opponents, func(i, j int) bool {
+		return out.Opponents[i].Name < out.Opponents[j].Name
+	})
+
+	out.Overall = Change{
+		Baseline:   baseline.Score,
+		Score:      current.Score,
+		Regression: baseline.Score-current.Score > threshold,
+	}
+	return out
+}
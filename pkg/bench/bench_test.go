@@ -0,0 +1,158 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bobertlo/gmars/pkg/cache"
+	"github.com/bobertlo/gmars/pkg/internal/testwarriors"
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBenchDir(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "imp.red"), []byte(testwarriors.Imp), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sit.rc"), []byte(testwarriors.Sit), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a warrior"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "old.red"), 0o755))
+	return dir
+}
+
+func newTestBench(t *testing.T) Bench {
+	config := testwarriors.Config()
+	opponents, err := LoadDir(writeBenchDir(t), config)
+	require.NoError(t, err)
+
+	dwarf := testwarriors.Parse(t, config, testwarriors.Dwarf)
+	return Bench{Config: config, Warrior: dwarf, Opponents: opponents, Rounds: 20, Seed: 3}
+}
+
+func TestLoadDir(t *testing.T) {
+	opponents, err := LoadDir(writeBenchDir(t), testwarriors.Config())
+	require.NoError(t, err)
+	require.Len(t, opponents, 2)
+	require.Equal(t, "imp.red", opponents[0].Name)
+	require.Equal(t, "Imp", opponents[0].Data.Name)
+	require.Equal(t, "sit.rc", opponents[1].Name)
+
+	_, err = LoadDir(t.TempDir(), testwarriors.Config())
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.red"), []byte("FOO.I $ 0, $ 0\n"), 0o644))
+	_, err = LoadDir(dir, testwarriors.Config())
+	require.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	b := newTestBench(t)
+	result, err := Run(context.Background(), b)
+	require.NoError(t, err)
+
+	require.Equal(t, "Dwarf", result.Warrior)
+	require.Len(t, result.Opponents, 2)
+	total := 0.0
+	for _, o := range result.Opponents {
+		require.Equal(t, b.Rounds, o.Wins+o.Losses+o.Ties)
+		require.InDelta(t, float64(3*o.Wins+o.Ties)*100/float64(b.Rounds), o.Score, 1e-9)
+		total += o.Score
+	}
+	require.InDelta(t, total/2, result.Score, 1e-9)
+
+	// results do not depend on parallelism
+	for _, workers := range []int{1, 3} {
+		b.Workers = workers
+		parallel, err := Run(context.Background(), b)
+		require.NoError(t, err)
+		require.Equal(t, result, parallel)
+	}
+
+	// a failing opponent is reported by name
+	failing := b
+	long := &mars.WarriorData{Code: make([]mars.Instruction, b.Config.Length+1)}
+	failing.Opponents = append([]Opponent{{Name: "long.red", Data: long}}, b.Opponents...)
+	_, err = Run(context.Background(), failing)
+	require.ErrorContains(t, err, "long.red")
+
+	// results against an opponent do not depend on the other opponents
+	b.Opponents = b.Opponents[1:]
+	single, err := Run(context.Background(), b)
+	require.NoError(t, err)
+	require.Equal(t, result.Opponents[1], single.Opponents[0])
+
+	b.Opponents = nil
+	_, err = Run(context.Background(), b)
+	require.Error(t, err)
+}
+
+func TestRunCache(t *testing.T) {
+	b := newTestBench(t)
+	expected, err := Run(context.Background(), b)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	b.Cache, err = cache.Open(dir)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		result, err := Run(context.Background(), b)
+		require.NoError(t, err)
+		require.Equal(t, expected, result)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+}
+
+func TestBaseline(t *testing.T) {
+	b := newTestBench(t)
+	result, err := Run(context.Background(), b)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, result.Save(path))
+	baseline, err := LoadResult(path)
+	require.NoError(t, err)
+	require.Equal(t, result, baseline)
+
+	comparison := Compare(baseline, result, 0)
+	require.Len(t, comparison.Opponents, 2)
+	for _, c := range append(comparison.Opponents, comparison.Overall) {
+		require.False(t, c.Regression)
+		require.Equal(t, 0.0, c.Delta())
+	}
+	require.False(t, comparison.Regression())
+
+	worse := *result
+	worse.Opponents = append([]OpponentResult{}, result.Opponents...)
+	worse.Opponents[0].Score -= 10
+	worse.Score -= 5
+	comparison = Compare(baseline, &worse, 5)
+	require.Equal(t, "imp.red", comparison.Opponents[0].Name)
+	require.True(t, comparison.Opponents[0].Regression)
+	require.False(t, comparison.Opponents[1].Regression)
+	require.False(t, comparison.Overall.Regression)
+	require.True(t, comparison.Regression())
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteChanges(buf, comparison))
+	require.Equal(t, 1, strings.Count(buf.String(), "REGRESSION"))
+
+	// an opponent named overall is not confused with the overall score
+	named := worse
+	named.Opponents = append([]OpponentResult{}, worse.Opponents...)
+	named.Opponents[1].Name = "overall"
+	comparison = Compare(&named, &named, 0)
+	require.Len(t, comparison.Opponents, 2)
+	require.Equal(t, "overall", comparison.Opponents[1].Name)
+	require.Equal(t, named.Score, comparison.Overall.Score)
+
+	buf.Reset()
+	require.NoError(t, result.WriteText(buf))
+	require.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 4)
+}
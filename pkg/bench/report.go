@@ -0,0 +1,53 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteText writes the results against each opponent followed by the
+// overall score
+func (r *Result) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	width := len("Opponent")
+	for _, o := range r.Opponents {
+		width = max(width, len(o.Name))
+	}
+
+	fmt.Fprintf(bw, "%-*s  %6s  %6s  %6s  %7s\n", width, "Opponent", "W", "L", "T", "Score")
+	for _, o := range r.Opponents {
+		fmt.Fprintf(bw, "%-*s  %6d  %6d  %6d  %7.2f\n", width, o.Name, o.Wins, o.Losses, o.Ties, o.Score)
+	}
+	fmt.Fprintf(bw, "%-*s  %6s  %6s  %6s  %7.2f\n", width, "Overall", "", "", "", r.Score)
+
+	return bw.Flush()
+}
+
+// WriteChanges writes a comparison returned by Compare, marking
+// regressions
+func WriteChanges(w io.Writer, c Comparison) error {
+	bw := bufio.NewWriter(w)
+
+	width := len("Opponent")
+	for _, o := range c.Opponents {
+		width = max(width, len(o.Name))
+	}
+
+	row := func(name string, change Change) {
+		mark := ""
+		if change.Regression {
+			mark = "  REGRESSION"
+		}
+		fmt.Fprintf(bw, "%-*s  %8.2f  %7.2f  %+7.2f%s\n", width, name, change.Baseline, change.Score, change.Delta(), mark)
+	}
+
+	fmt.Fprintf(bw, "%-*s  %8s  %7s  %7s\n", width, "Opponent", "Baseline", "Score", "Delta")
+	for _, o := range c.Opponents {
+		row(o.Name, o)
+	}
+	row("Overall", c.Overall)
+
+	return bw.Flush()
+}
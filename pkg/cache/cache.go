@@ -0,0 +1,144 @@
+// Package cache stores match results on disk, keyed by the hash returned
+// by mars.Match.Key, so pairings that were already played are not
+// simulated again.
+package cache
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bobertlo/gmars/pkg/mars"
+)
+
+// Cache is a directory of match results. Each result is stored as a JSON
+// file named after its key.
+type Cache struct {
+	dir string
+}
+
+// Open returns the cache stored in dir, creating the directory if needed
+func Open(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Dir returns the directory of the cache
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) path(key string) (string, error) {
+	if len(key) < 3 {
+		return "", fmt.Errorf("invalid cache key '%s'", key)
+	}
+	if _, err := hex.DecodeString(key); err != nil {
+		return "", fmt.Errorf("invalid cache key '%s'", key)
+	}
+	return filepath.Join(c.dir, key[:2], key+".json"), nil
+}
+
+// Get returns the result stored for key. The boolean is false if there is
+// no result for key or the stored result can not be decoded, so a corrupt
+// entry is replaced by the next Put.
+func (c *Cache) Get(key string) (mars.MatchResult, bool, error) {
+	path, err := c.path(key)
+	if err != nil {
+		return mars.MatchResult{}, false, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return mars.MatchResult{}, false, nil
+	} else if err != nil {
+		return mars.MatchResult{}, false, err
+	}
+	defer f.Close()
+
+	var result mars.MatchResult
+	if err := json.NewDecoder(f).Decode(&result); err != nil {
+		return mars.MatchResult{}, false, nil
+	}
+	return result, true, nil
+}
+
+// Put stores the result for key, replacing it atomically
+func (c *Cache) Put(key string, result mars.MatchResult) error {
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(result); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// fits returns true if a stored result has the shape of the results of m,
+// so it is safe to index by warrior
+func fits(result mars.MatchResult, m mars.Match) bool {
+	n := len(m.Warriors)
+	if result.Rounds != m.RoundCount() || len(result.Wins) != n || len(result.Losses) != n ||
+		len(result.Ties) != n || len(result.Survived) != n {
+		return false
+	}
+	for _, s := range result.Survived {
+		if len(s) != n {
+			return false
+		}
+	}
+	if m.Permute || m.RecordPositions {
+		return len(result.Positions) == result.Rounds
+	}
+	return len(result.Positions) == 0
+}
+
+// RunMatch returns the cached result of a match, running the match and
+// storing its result if it is not in the cache. A nil cache always runs
+// the match. A stored result that does not fit the match is treated as
+// corrupt and replaced. The boolean is true if the result came from the
+// cache.
+func RunMatch(ctx context.Context, c *Cache, m mars.Match) (mars.MatchResult, bool, error) {
+	if c == nil {
+		result, err := mars.RunMatch(ctx, m)
+		return result, false, err
+	}
+
+	key := m.Key()
+	result, ok, err := c.Get(key)
+	if err != nil {
+		return mars.MatchResult{}, false, err
+	}
+	if ok && fits(result, m) {
+		return result, true, nil
+	}
+
+	result, err = mars.RunMatch(ctx, m)
+	if err != nil {
+		return mars.MatchResult{}, false, err
+	}
+	if err := c.Put(key, result); err != nil {
+		return mars.MatchResult{}, false, err
+	}
+	return result, false, nil
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bobertlo/gmars/pkg/internal/testwarriors"
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/stretchr/testify/require"
+)
+
+func testMatch(t *testing.T) mars.Match {
+	config := testwarriors.Config()
+	warriors := []*mars.WarriorData{
+		testwarriors.Parse(t, config, testwarriors.Dwarf),
+		testwarriors.Parse(t, config, testwarriors.Imp),
+	}
+	return mars.Match{Config: config, Warriors: warriors, Rounds: 20, Seed: 7}
+}
+
+func TestGetPut(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "cache"))
+	require.NoError(t, err)
+
+	m := testMatch(t)
+	key := m.Key()
+	_, ok, err := c.Get(key)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	result := mars.NewMatchResult(2)
+	result.Rounds = 3
+	result.Wins[0] = 3
+	result.Losses[1] = 3
+	result.Positions = []mars.PositionResult{{Offset: 100, Winner: 0, Cycles: 50}}
+	require.NoError(t, c.Put(key, result))
+
+	got, ok, err := c.Get(key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, result, got)
+
+	_, _, err = c.Get("../x")
+	require.Error(t, err)
+	require.Error(t, c.Put("", result))
+
+	path, err := c.path(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, ok, err = c.Get(key)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestRunMatch(t *testing.T) {
+	c, err := Open(t.TempDir())
+	require.NoError(t, err)
+	m := testMatch(t)
+
+	expected, err := mars.RunMatch(context.Background(), m)
+	require.NoError(t, err)
+
+	result, cached, err := RunMatch(context.Background(), c, m)
+	require.NoError(t, err)
+	require.False(t, cached)
+	require.Equal(t, expected, result)
+
+	result, cached, err = RunMatch(context.Background(), c, m)
+	require.NoError(t, err)
+	require.True(t, cached)
+	require.Equal(t, expected, result)
+
+	// a nil cache runs the match
+	result, cached, err = RunMatch(context.Background(), nil, m)
+	require.NoError(t, err)
+	require.False(t, cached)
+	require.Equal(t, expected, result)
+
+	// corrupt entries and entries that do not fit the match are run again
+	// and replaced
+	path, err := c.path(m.Key())
+	require.NoError(t, err)
+	wrongCount, err := json.Marshal(mars.NewMatchResult(3))
+	require.NoError(t, err)
+	for _, entry := range []string{"{", "null", "{}", `{"rounds": 20}`, string(wrongCount)} {
+		require.NoError(t, os.WriteFile(path, []byte(entry), 0o644))
+		result, cached, err = RunMatch(context.Background(), c, m)
+		require.NoError(t, err, entry)
+		require.False(t, cached, entry)
+		require.Equal(t, expected, result, entry)
+		result, cached, err = RunMatch(context.Background(), c, m)
+		require.NoError(t, err, entry)
+		require.True(t, cached, entry)
+		require.Equal(t, expected, result, entry)
+	}
+
+	m.Rounds = 0
+	m.Warriors = nil
+	_, _, err = RunMatch(context.Background(), c, m)
+	require.Error(t, err)
+}
@@ -0,0 +1,417 @@
+// Package hill manages a king-of-the-hill: a fixed size set of resident
+// warriors ranked by their scores against each other. The hill state is
+// kept on disk so matches played for earlier challenges are not repeated.
+package hill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/bobertlo/gmars/pkg/score"
+)
+
+// Settings holds the rules of a hill
+type Settings struct {
+	Config mars.SimulatorConfig `json:"config"`
+
+	// Size is the number of warriors kept on the hill
+	Size int `json:"size"`
+
+	// Rounds is the number of rounds played by each pairing
+	Rounds int `json:"rounds"`
+
+	// Seed selects the starting positions. Each pairing uses a seed derived
+	// from Seed and the IDs of the warriors.
+	Seed int64 `json:"seed"`
+
+	// Scoring is the score formula passed to score.Parse, or empty for the
+	// standard 3/1/0 score
+	Scoring string `json:"scoring,omitempty"`
+}
+
+// validate checks the settings of a hill
+func (s *Settings) validate() error {
+	if s.Size < 1 {
+		return fmt.Errorf("invalid hill size %d", s.Size)
+	}
+	if s.Rounds < 1 {
+		return fmt.Errorf("invalid round count %d", s.Rounds)
+	}
+	scorer, err := score.Parse(s.Scoring)
+	if err != nil {
+		return err
+	}
+	if err := score.Validate(scorer, 2); err != nil {
+		return err
+	}
+	return s.Config.Validate()
+}
+
+// scorer returns the scorer selected by the settings. The settings must
+// have been validated.
+func (s *Settings) scorer() score.Scorer {
+	scorer, err := score.Parse(s.Scoring)
+	if err != nil {
+		return score.Standard
+	}
+	return scorer
+}
+
+// Resident is a warrior on the hill
+type Resident struct {
+	ID       int                `json:"id"`
+	Name     string             `json:"name"`
+	Author   string             `json:"author"`
+	Strategy string             `json:"strategy,omitempty"`
+	Code     []mars.Instruction `json:"code"`
+	Start    int                `json:"start"`
+
+	// Age is the number of successful challenges the warrior has survived
+	Age int `json:"age"`
+}
+
+// Data returns the WarriorData of a resident
+func (r *Resident) Data() *mars.WarriorData {
+	return &mars.WarriorData{
+		Name:     r.Name,
+		Author:   r.Author,
+		Strategy: r.Strategy,
+		Code:     r.Code,
+		Start:    r.Start,
+	}
+}
+
+// Pairing holds the results of the warrior with ID A against the warrior
+// with ID B, where A < B
+type Pairing struct {
+	A      int `json:"a"`
+	B      int `json:"b"`
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Ties   int `json:"ties"`
+}
+
+// Hill is the state of a hill
+type Hill struct {
+	Settings  Settings    `json:"settings"`
+	NextID    int         `json:"next_id"`
+	Residents []*Resident `json:"residents"`
+	Results   []Pairing   `json:"results"`
+
+	// Workers is the number of simulators run in parallel, or 0 to use
+	// GOMAXPROCS
+	Workers int `json:"-"`
+}
+
+// New returns an empty hill
+func New(settings Settings) (*Hill, error) {
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+	return &Hill{Settings: settings, NextID: 1}, nil
+}
+
+// Load reads a hill from a JSON file
+func Load(path string) (*Hill, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := &Hill{}
+	if err := json.NewDecoder(f).Decode(h); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if err := h.Settings.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if err := h.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return h, nil
+}
+
+// validate checks that the residents and results of a hill are consistent
+func (h *Hill) validate() error {
+	ids := make(map[int]bool, len(h.Residents))
+	for _, r := range h.Residents {
+		if r == nil {
+			return fmt.Errorf("missing resident")
+		}
+		if r.ID < 1 || ids[r.ID] {
+			return fmt.Errorf("invalid or duplicate resident ID %d", r.ID)
+		}
+		if n := mars.Address(len(r.Code)); n == 0 || n > h.Settings.Config.Length {
+			return fmt.Errorf("resident %d: invalid warrior length %d", r.ID, len(r.Code))
+		}
+		if h.NextID <= r.ID {
+			return fmt.Errorf("next ID %d is not above resident ID %d", h.NextID, r.ID)
+		}
+		ids[r.ID] = true
+	}
+	if h.NextID < 1 {
+		return fmt.Errorf("invalid next ID %d", h.NextID)
+	}
+
+	pairs := make(map[[2]int]bool, len(h.Results))
+	for _, p := range h.Results {
+		if p.A >= p.B || !ids[p.A] || !ids[p.B] {
+			return fmt.Errorf("result for unknown pairing %d vs %d", p.A, p.B)
+		}
+		if pairs[[2]int{p.A, p.B}] {
+			return fmt.Errorf("duplicate result for %d vs %d", p.A, p.B)
+		}
+		if p.Wins < 0 || p.Losses < 0 || p.Ties < 0 || p.Wins+p.Losses+p.Ties != h.Settings.Rounds {
+			return fmt.Errorf("result for %d vs %d does not have %d rounds", p.A, p.B, h.Settings.Rounds)
+		}
+		pairs[[2]int{p.A, p.B}] = true
+	}
+	return nil
+}
+
+// Save writes a hill to a JSON file, replacing it atomically. The file
+// keeps the permissions of the file it replaces, or 0644 if it is new.
+func (h *Hill) Save(path string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(h); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// pairSeed returns the match seed for the warriors with IDs a < b
+func (h *Hill) pairSeed(a, b int) int64 {
+	return h.Settings.Seed ^ int64((uint64(a)<<32|uint64(b))*0x9e3779b97f4a7c15)
+}
+
+// pairings returns the index of each result by the IDs of its warriors
+func (h *Hill) pairings() map[[2]int]int {
+	out := make(map[[2]int]int, len(h.Results))
+	for i, p := range h.Results {
+		out[[2]int{p.A, p.B}] = i
+	}
+	return out
+}
+
+// Update plays every pairing of residents that has no stored result and
+// returns the number of matches played
+func (h *Hill) Update(ctx context.Context) (int, error) {
+	known := h.pairings()
+	played := 0
+	for i, x := range h.Residents {
+		for _, y := range h.Residents[i+1:] {
+			a, b := x, y
+			if a.ID > b.ID {
+				a, b = b, a
+			}
+			if _, ok := known[[2]int{a.ID, b.ID}]; ok {
+				continue
+			}
+
+			match := mars.Match{
+				Config:   h.Settings.Config,
+				Warriors: []*mars.WarriorData{a.Data(), b.Data()},
+				Rounds:   h.Settings.Rounds,
+				Seed:     h.pairSeed(a.ID, b.ID),
+				Workers:  h.Workers,
+			}
+			result, err := mars.RunMatch(ctx, match)
+			if err != nil {
+				return played, fmt.Errorf("%s vs %s: %s", a.Name, b.Name, err)
+			}
+			h.Results = append(h.Results, Pairing{
+				A:      a.ID,
+				B:      b.ID,
+				Wins:   result.Wins[0],
+				Losses: result.Losses[0],
+				Ties:   result.Ties[0],
+			})
+			known[[2]int{a.ID, b.ID}] = len(h.Results) - 1
+			played++
+		}
+	}
+	return played, nil
+}
+
+// Standing is the position of a resident on the hill
+type Standing struct {
+	Rank     int
+	Resident *Resident
+	Wins     int
+	Losses   int
+	Ties     int
+
+	// Score is the points earned per 100 rounds played
+	Score float64
+}
+
+// Standings returns the residents ordered by score. Only stored results
+// are counted; call Update first to play missing pairings. Residents with
+// equal scores share a rank and keep their order on the hill.
+func (h *Hill) Standings() []Standing {
+	index := make(map[int]int, len(h.Residents))
+	out := make([]Standing, len(h.Residents))
+	for i, r := range h.Residents {
+		index[r.ID] = i
+		out[i].Resident = r
+	}
+
+	for _, p := range h.Results {
+		a, okA := index[p.A]
+		b, okB := index[p.B]
+		if !okA || !okB {
+			continue
+		}
+		out[a].Wins += p.Wins
+		out[a].Losses += p.Losses
+		out[a].Ties += p.Ties
+		out[b].Wins += p.Losses
+		out[b].Losses += p.Wins
+		out[b].Ties += p.Ties
+	}
+
+	scorer := h.Settings.scorer()
+	for i := range out {
+		s := &out[i]
+		s.Score = score.PerRound(score.Pair(scorer, s.Wins, s.Ties), s.Wins+s.Losses+s.Ties)
+	}
+
+	sort.SliceStable(out, func(a, b int) bool {
+		return out[a].Score > out[b].Score
+	})
+	for i := range out {
+		if i > 0 && out[i].Score == out[i-1].Score {
+			out[i].Rank = out[i-1].Rank
+		} else {
+			out[i].Rank = i + 1
+		}
+	}
+	return out
+}
+
+// ChallengeResult describes the outcome of a challenge
+type ChallengeResult struct {
+	Challenger *Resident
+
+	// Rank is the position of the challenger on the hill, or 0 if it did
+	// not stay on the hill
+	Rank int
+
+	// PushedOff lists the warriors removed from the hill
+	PushedOff []*Resident
+
+	// Played is the number of matches played
+	Played int
+}
+
+// Challenge plays a warrior against every resident, ranks the hill and
+// pushes off the lowest ranked warriors until the hill fits its size. If
+// the challenger stays on the hill, the other residents age by one.
+func (h *Hill) Challenge(ctx context.Context, data *mars.WarriorData) (*ChallengeResult, error) {
+	if n := mars.Address(len(data.Code)); n == 0 || n > h.Settings.Config.Length {
+		return nil, fmt.Errorf("invalid warrior length %d", len(data.Code))
+	}
+
+	data = data.Copy()
+	challenger := &Resident{
+		ID:       h.NextID,
+		Name:     data.Name,
+		Author:   data.Author,
+		Strategy: data.Strategy,
+		Code:     data.Code,
+		Start:    data.Start,
+	}
+	residents := h.Residents
+	results := h.Results
+	h.NextID++
+	h.Residents = append(h.Residents, challenger)
+
+	played, err := h.Update(ctx)
+	if err != nil {
+		// leave the hill as it was, keeping the new ID reserved
+		h.Residents = residents
+		h.Results = results
+		return nil, err
+	}
+
+	result := &ChallengeResult{Challenger: challenger, Played: played}
+	for len(h.Residents) > h.Settings.Size {
+		standings := h.Standings()
+		last := standings[len(standings)-1].Resident
+		result.PushedOff = append(result.PushedOff, last)
+		h.remove(last.ID)
+	}
+
+	// scores change without the results of the warriors pushed off
+	for _, s := range h.Standings() {
+		if s.Resident == challenger {
+			result.Rank = s.Rank
+		}
+	}
+	if result.Rank > 0 {
+		for _, r := range h.Residents {
+			if r != challenger {
+				r.Age++
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Remove removes the resident with the given name and its results. It
+// returns false if there is no such resident.
+func (h *Hill) Remove(name string) bool {
+	for _, r := range h.Residents {
+		if r.Name == name {
+			h.remove(r.ID)
+			return true
+		}
+	}
+	return false
+}
+
+// remove removes a resident and its results by ID
+func (h *Hill) remove(id int) {
+	residents := h.Residents[:0]
+	for _, r := range h.Residents {
+		if r.ID != id {
+			residents = append(residents, r)
+		}
+	}
+	h.Residents = residents
+
+	results := h.Results[:0]
+	for _, p := range h.Results {
+		if p.A != id && p.B != id {
+			results = append(results, p)
+		}
+	}
+	h.Results = results
+}
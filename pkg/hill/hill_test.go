@@ -0,0 +1,208 @@
+package hill
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bobertlo/gmars/pkg/internal/testwarriors"
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHill(t *testing.T, size int) *Hill {
+	h, err := New(Settings{Config: testwarriors.Config(), Size: size, Rounds: 10, Seed: 5})
+	require.NoError(t, err)
+	return h
+}
+
+func TestChallenge(t *testing.T) {
+	h := newTestHill(t, 2)
+	config := h.Settings.Config
+
+	result, err := h.Challenge(context.Background(), testwarriors.Parse(t, config, testwarriors.Imp))
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Rank)
+	require.Equal(t, 0, result.Played)
+
+	result, err = h.Challenge(context.Background(), testwarriors.Parse(t, config, testwarriors.Dwarf))
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Played)
+	require.Empty(t, result.PushedOff)
+	require.Equal(t, 1, h.Residents[0].Age)
+
+	// a warrior that dies immediately does not make the hill
+	result, err = h.Challenge(context.Background(), testwarriors.Parse(t, config, testwarriors.Die))
+	require.NoError(t, err)
+	require.Equal(t, 0, result.Rank)
+	require.Equal(t, 2, result.Played)
+	require.Len(t, result.PushedOff, 1)
+	require.Equal(t, "Die", result.PushedOff[0].Name)
+	require.Len(t, h.Residents, 2)
+	require.Len(t, h.Results, 1)
+	require.Equal(t, 1, h.Residents[0].Age)
+	require.Equal(t, 0, h.Residents[1].Age)
+
+	// only the matches against the challenger are played
+	result, err = h.Challenge(context.Background(), testwarriors.Parse(t, config, testwarriors.Sit))
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Played)
+	require.Len(t, h.Residents, 2)
+	require.Len(t, h.Results, 1)
+	require.Equal(t, 5, h.NextID)
+
+	for _, s := range h.Standings() {
+		require.Equal(t, h.Settings.Rounds, s.Wins+s.Losses+s.Ties)
+		if s.Resident == result.Challenger {
+			require.Equal(t, s.Rank, result.Rank)
+		}
+	}
+}
+
+func TestStandingsSharedRank(t *testing.T) {
+	h := newTestHill(t, 3)
+	h.Residents = []*Resident{{ID: 1}, {ID: 2}, {ID: 3}}
+	h.Results = []Pairing{
+		{A: 1, B: 2, Ties: 10},
+		{A: 1, B: 3, Wins: 10},
+		{A: 2, B: 3, Wins: 10},
+	}
+
+	standings := h.Standings()
+	ids := []int{standings[0].Resident.ID, standings[1].Resident.ID, standings[2].Resident.ID}
+	require.Equal(t, []int{1, 2, 3}, ids)
+	require.Equal(t, []int{1, 1, 3}, []int{standings[0].Rank, standings[1].Rank, standings[2].Rank})
+}
+
+func TestChallengeInvalid(t *testing.T) {
+	h := newTestHill(t, 2)
+	_, err := h.Challenge(context.Background(), &mars.WarriorData{Name: "Empty"})
+	require.Error(t, err)
+	require.Empty(t, h.Residents)
+
+	_, err = New(Settings{Config: h.Settings.Config, Size: 0, Rounds: 10})
+	require.Error(t, err)
+	_, err = New(Settings{Config: h.Settings.Config, Size: 10, Rounds: 0})
+	require.Error(t, err)
+	_, err = New(Settings{Config: h.Settings.Config, Size: 10, Rounds: 10, Scoring: "3/(S-1)"})
+	require.Error(t, err)
+}
+
+func TestUpdateDeterministic(t *testing.T) {
+	h1 := newTestHill(t, 5)
+	h2 := newTestHill(t, 5)
+	h2.Workers = 1
+	config := h1.Settings.Config
+	for _, h := range []*Hill{h1, h2} {
+		for _, name := range []string{"Imp", "Dwarf", "Sit"} {
+			code := map[string]string{"Imp": testwarriors.Imp, "Dwarf": testwarriors.Dwarf, "Sit": testwarriors.Sit}[name]
+			_, err := h.Challenge(context.Background(), testwarriors.Parse(t, config, code))
+			require.NoError(t, err)
+		}
+	}
+	require.Equal(t, h1.Results, h2.Results)
+
+	// removing stored results and updating plays the same matches again
+	results := append([]Pairing{}, h1.Results...)
+	h1.Results = h1.Results[:1]
+	played, err := h1.Update(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, played)
+	require.ElementsMatch(t, results, h1.Results)
+}
+
+func TestSaveLoad(t *testing.T) {
+	h := newTestHill(t, 3)
+	config := h.Settings.Config
+	for _, code := range []string{testwarriors.Imp, testwarriors.Dwarf} {
+		_, err := h.Challenge(context.Background(), testwarriors.Parse(t, config, code))
+		require.NoError(t, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "hill.json")
+	require.NoError(t, h.Save(path))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, h, loaded)
+
+	// saving keeps the permissions of the replaced file
+	require.NoError(t, os.Chmod(path, 0640))
+	require.NoError(t, h.Save(path))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	require.True(t, loaded.Remove("Imp"))
+	require.False(t, loaded.Remove("Imp"))
+	require.Len(t, loaded.Residents, 1)
+	require.Empty(t, loaded.Results)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	h := newTestHill(t, 3)
+	config := h.Settings.Config
+	for _, code := range []string{testwarriors.Imp, testwarriors.Dwarf} {
+		_, err := h.Challenge(context.Background(), testwarriors.Parse(t, config, code))
+		require.NoError(t, err)
+	}
+
+	changes := []func(h *Hill){
+		func(h *Hill) { h.Residents[1].ID = h.Residents[0].ID },
+		func(h *Hill) { h.NextID = h.Residents[1].ID },
+		func(h *Hill) { h.Residents[0].Code = nil },
+		func(h *Hill) { h.Residents = append(h.Residents, nil) },
+		func(h *Hill) { h.Results[0].B = 99 },
+		func(h *Hill) { h.Results[0].A, h.Results[0].B = h.Results[0].B, h.Results[0].A },
+		func(h *Hill) { h.Results = append(h.Results, h.Results[0]) },
+		func(h *Hill) { h.Results[0].Wins++ },
+	}
+	path := filepath.Join(t.TempDir(), "hill.json")
+	for i, change := range changes {
+		changed := *h
+		changed.Residents = make([]*Resident, len(h.Residents))
+		for j, r := range h.Residents {
+			copied := *r
+			changed.Residents[j] = &copied
+		}
+		changed.Results = append([]Pairing{}, h.Results...)
+		change(&changed)
+
+		require.NoError(t, changed.Save(path))
+		_, err := Load(path)
+		require.Error(t, err, "change %d", i)
+	}
+
+	require.NoError(t, h.Save(path))
+	_, err := Load(path)
+	require.NoError(t, err)
+}
+
+func TestReport(t *testing.T) {
+	h := newTestHill(t, 2)
+	config := h.Settings.Config
+	_, err := h.Challenge(context.Background(), testwarriors.Parse(t, config, testwarriors.Imp))
+	require.NoError(t, err)
+	result, err := h.Challenge(context.Background(), testwarriors.Parse(t, config, testwarriors.Dwarf))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, h.WriteChallengeReport(buf, result))
+	out := buf.String()
+	require.Contains(t, out, "Dwarf by test placed")
+	require.Contains(t, out, "Opponent")
+	require.Contains(t, out, " #  %W/ %L/ %T")
+
+	buf.Reset()
+	require.NoError(t, h.WriteReport(buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+}
@@ -0,0 +1,97 @@
+package hill
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/bobertlo/gmars/pkg/score"
+)
+
+// percent returns n as a whole percentage of total
+func percent(n, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return (n*100 + total/2) / total
+}
+
+// WriteReport writes the standings of the hill in the format used by KOTH
+// hill reports
+func (h *Hill) WriteReport(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	c := h.Settings.Config
+	fmt.Fprintf(bw, "Hill: %d warriors, %d rounds, core %d, cycles %d, processes %d, length %d, distance %d\n",
+		h.Settings.Size, h.Settings.Rounds, c.CoreSize, c.Cycles, c.Processes, c.Length, c.Distance)
+	fmt.Fprintf(bw, " #  %%W/ %%L/ %%T  %-24s %-20s %7s %4s\n", "Name", "Author", "Score", "Age")
+	for _, s := range h.Standings() {
+		total := s.Wins + s.Losses + s.Ties
+		fmt.Fprintf(bw, "%2d %3d/%3d/%3d  %-24s %-20s %7.1f %4d\n", s.Rank,
+			percent(s.Wins, total), percent(s.Losses, total), percent(s.Ties, total),
+			truncate(s.Resident.Name, 24), truncate(s.Resident.Author, 20), s.Score, s.Resident.Age)
+	}
+
+	return bw.Flush()
+}
+
+// WriteChallengeReport writes the outcome of a challenge followed by the
+// results of the challenger against each resident and the hill standings
+func (h *Hill) WriteChallengeReport(w io.Writer, result *ChallengeResult) error {
+	bw := bufio.NewWriter(w)
+
+	challenger := result.Challenger
+	if result.Rank > 0 {
+		fmt.Fprintf(bw, "%s by %s placed %d on the hill\n", challenger.Name, challenger.Author, result.Rank)
+	} else {
+		fmt.Fprintf(bw, "%s by %s did not make the hill\n", challenger.Name, challenger.Author)
+	}
+	for _, r := range result.PushedOff {
+		if r != challenger {
+			fmt.Fprintf(bw, "%s by %s fell off the hill (age %d)\n", r.Name, r.Author, r.Age)
+		}
+	}
+
+	if result.Rank > 0 && len(h.Residents) > 1 {
+		scorer := h.Settings.scorer()
+		fmt.Fprintf(bw, "\n%-24s %6s %6s %6s %7s\n", "Opponent", "W", "L", "T", "Score")
+		for _, r := range h.Residents {
+			if r == challenger {
+				continue
+			}
+			p, ok := h.result(challenger.ID, r.ID)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(bw, "%-24s %6d %6d %6d %7.1f\n", truncate(r.Name, 24), p.Wins, p.Losses, p.Ties,
+				score.PerRound(score.Pair(scorer, p.Wins, p.Ties), p.Wins+p.Losses+p.Ties))
+		}
+	}
+
+	fmt.Fprintln(bw)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return h.WriteReport(w)
+}
+
+// result returns the stored results of warrior a against warrior b, from
+// the point of view of a
+func (h *Hill) result(a, b int) (Pairing, bool) {
+	for _, p := range h.Results {
+		switch {
+		case p.A == a && p.B == b:
+			return p, true
+		case p.A == b && p.B == a:
+			return Pairing{A: a, B: b, Wins: p.Losses, Losses: p.Wins, Ties: p.Ties}, true
+		}
+	}
+	return Pairing{}, false
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
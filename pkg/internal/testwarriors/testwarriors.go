@@ -0,0 +1,40 @@
+// Package testwarriors provides the small warriors and settings shared by
+// the tests of the match running packages.
+package testwarriors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/stretchr/testify/require"
+)
+
+// Load files of warriors with predictable results against each other
+const (
+	Imp   = ";name Imp\n;author test\nMOV.I $ 0, $ 1\n"
+	Dwarf = ";name Dwarf\n;author test\nADD.AB # 4, $ 3\nMOV.I $ 2, @ 2\nJMP.B $ -2, $ 0\nDAT.F # 0, # 0\n"
+	Sit   = ";name Sit\n;author test\nJMP.B $ 0, $ 0\n"
+	Die   = ";name Die\n;author test\nDAT.F $ 0, $ 0\n"
+)
+
+// Config returns the 94nop config with fewer cycles, so tests run quickly
+func Config() mars.SimulatorConfig {
+	config := mars.ConfigNOP94()
+	config.Cycles = 8000
+	return config
+}
+
+// Parse parses a load file, failing the test on errors
+func Parse(t testing.TB, config mars.SimulatorConfig, code string) *mars.WarriorData {
+	data, err := mars.ParseLoadFile(strings.NewReader(code), config)
+	require.NoError(t, err)
+	return &data
+}
+
+// ParseNamed parses a load file and renames the warrior
+func ParseNamed(t testing.TB, config mars.SimulatorConfig, name, code string) *mars.WarriorData {
+	data := Parse(t, config, code)
+	data.Name = name
+	return data
+}
@@ -0,0 +1,126 @@
+package mars
+
+// Operand identifies the operand of an instruction responsible for a memory
+// access
+type Operand uint8
+
+const (
+	OperandNone Operand = iota
+	OperandA
+	OperandB
+)
+
+func (o Operand) String() string {
+	switch o {
+	case OperandNone:
+		return "none"
+	case OperandA:
+		return "A"
+	case OperandB:
+		return "B"
+	default:
+		return "?"
+	}
+}
+
+func (f Field) String() string {
+	switch f {
+	case FieldInstruction:
+		return "I"
+	case FieldA:
+		return "A"
+	case FieldB:
+		return "B"
+	default:
+		return "?"
+	}
+}
+
+var instructionFields = []Field{FieldInstruction}
+
+// sourceFields lists the fields of the A operand used by each OpMode
+var sourceFields = [I + 1][]Field{
+	F:  {FieldA, FieldB},
+	A:  {FieldA},
+	B:  {FieldB},
+	AB: {FieldA},
+	BA: {FieldB},
+	X:  {FieldA, FieldB},
+	I:  {FieldA, FieldB},
+}
+
+// targetFields lists the fields of the B operand used by each OpMode. Each
+// field is paired with the source field at the same index.
+var targetFields = [I + 1][]Field{
+	F:  {FieldA, FieldB},
+	A:  {FieldA},
+	B:  {FieldB},
+	AB: {FieldB},
+	BA: {FieldA},
+	X:  {FieldB, FieldA},
+	I:  {FieldA, FieldB},
+}
+
+// operandReadFields returns the fields of the instruction referenced by an
+// operand that op reads
+func operandReadFields(op OpCode, mode OpMode, operand Operand) []Field {
+	if mode > I {
+		return nil
+	}
+
+	switch op {
+	case MOV:
+		if operand == OperandB {
+			return nil
+		}
+		if mode == I {
+			return instructionFields
+		}
+		return sourceFields[mode]
+	case CMP, SEQ, SNE:
+		if mode == I {
+			return instructionFields
+		}
+	case ADD, SUB, MUL, DIV, MOD, SLT:
+	case JMZ, JMN:
+		if operand == OperandA {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if operand == OperandA {
+		return sourceFields[mode]
+	}
+	return targetFields[mode]
+}
+
+// reportAccess reports a memory access by w caused by an operand
+func (s *reportSim) reportAccess(t ReportType, w *warrior, a Address, field Field, operand Operand) {
+	s.Report(Report{Type: t, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: a, Field: field, Operand: operand})
+}
+
+// reportFields reports an access to each of fields at address a
+func (s *reportSim) reportFields(t ReportType, w *warrior, a Address, fields []Field, operand Operand) {
+	for _, f := range fields {
+		s.reportAccess(t, w, a, f, operand)
+	}
+}
+
+// reportDivWrites reports the fields written by a DIV or MOD instruction,
+// skipping fields with a zero divisor
+func (s *reportSim) reportDivWrites(w *warrior, IR, IRA cell, WAB Address) {
+	if IR.OpMode > I {
+		return
+	}
+	for i, f := range targetFields[IR.OpMode] {
+		src := IRA.A
+		if sourceFields[IR.OpMode][i] == FieldB {
+			src = IRA.B
+		}
+		if src != 0 {
+			s.reportAccess(WarriorWrite, w, WAB, f, OperandB)
+		}
+	}
+}
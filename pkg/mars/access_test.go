@@ -0,0 +1,184 @@
+package mars
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type access struct {
+	Type    ReportType
+	Address Address
+	Field   Field
+	Operand Operand
+}
+
+func rd(a Address, f Field, o Operand) access  { return access{WarriorRead, a, f, o} }
+func wr(a Address, f Field, o Operand) access  { return access{WarriorWrite, a, f, o} }
+func inc(a Address, f Field, o Operand) access { return access{WarriorIncrement, a, f, o} }
+func dec(a Address, f Field, o Operand) access { return access{WarriorDecrement, a, f, o} }
+
+type accessTest struct {
+	input    []string
+	expected []access
+}
+
+func runAccessTests(t *testing.T, setName string, tests []accessTest) {
+	for i, test := range tests {
+		config := NewQuickConfig(ICWS94, 16, 16, 1, 16)
+		config.Distance = 0
+
+		code := make([]Instruction, len(test.input))
+		for j, in := range test.input {
+			code[j] = parseTestInstruction(t, in, 16)
+		}
+
+		sim, err := newReportSim(config)
+		require.NoError(t, err)
+		_, err = sim.AddWarrior(&WarriorData{Code: code})
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(0, 0))
+
+		reports := reportRecorder{}
+		sim.AddReporter(&reports)
+		sim.RunCycle()
+
+		accesses := []access{}
+		for _, r := range reports {
+			switch r.Type {
+			case WarriorRead, WarriorWrite, WarriorIncrement, WarriorDecrement:
+				accesses = append(accesses, access{r.Type, r.Address, r.Field, r.Operand})
+			}
+		}
+		require.Equal(t, test.expected, accesses, fmt.Sprintf("%s test %d: %s", setName, i, test.input[0]))
+	}
+}
+
+func TestAccessAddressModes(t *testing.T) {
+	ptr := "dat.f $3, $5"
+	tests := []accessTest{
+		// A operand
+		{[]string{"mov.i #1, $2"}, []access{wr(2, FieldInstruction, OperandB)}},
+		{[]string{"mov.i $1, $2"}, []access{
+			rd(1, FieldInstruction, OperandA), wr(2, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i *1, $2", ptr}, []access{
+			rd(1, FieldA, OperandA), rd(4, FieldInstruction, OperandA), wr(2, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i @1, $2", ptr}, []access{
+			rd(1, FieldB, OperandA), rd(6, FieldInstruction, OperandA), wr(2, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i {1, $2", ptr}, []access{
+			dec(1, FieldA, OperandA), rd(1, FieldA, OperandA), rd(3, FieldInstruction, OperandA),
+			wr(2, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i <1, $2", ptr}, []access{
+			dec(1, FieldB, OperandA), rd(1, FieldB, OperandA), rd(5, FieldInstruction, OperandA),
+			wr(2, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i }1, $2", ptr}, []access{
+			rd(1, FieldA, OperandA), rd(4, FieldInstruction, OperandA), inc(1, FieldA, OperandA),
+			wr(2, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i >1, $2", ptr}, []access{
+			rd(1, FieldB, OperandA), rd(6, FieldInstruction, OperandA), inc(1, FieldB, OperandA),
+			wr(2, FieldInstruction, OperandB),
+		}},
+
+		// B operand
+		{[]string{"add.ab #4, #1"}, []access{wr(0, FieldB, OperandB)}},
+		{[]string{"mov.i $0, $1"}, []access{
+			rd(0, FieldInstruction, OperandA), wr(1, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i $0, *1", ptr}, []access{
+			rd(0, FieldInstruction, OperandA), rd(1, FieldA, OperandB), wr(4, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i $0, @1", ptr}, []access{
+			rd(0, FieldInstruction, OperandA), rd(1, FieldB, OperandB), wr(6, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i $0, {1", ptr}, []access{
+			rd(0, FieldInstruction, OperandA), dec(1, FieldA, OperandB), rd(1, FieldA, OperandB),
+			wr(3, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i $0, <1", ptr}, []access{
+			rd(0, FieldInstruction, OperandA), dec(1, FieldB, OperandB), rd(1, FieldB, OperandB),
+			wr(5, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i $0, }1", ptr}, []access{
+			rd(0, FieldInstruction, OperandA), rd(1, FieldA, OperandB), inc(1, FieldA, OperandB),
+			wr(4, FieldInstruction, OperandB),
+		}},
+		{[]string{"mov.i $0, >1", ptr}, []access{
+			rd(0, FieldInstruction, OperandA), rd(1, FieldB, OperandB), inc(1, FieldB, OperandB),
+			wr(6, FieldInstruction, OperandB),
+		}},
+	}
+	runAccessTests(t, "address modes", tests)
+}
+
+func TestAccessOpcodes(t *testing.T) {
+	tests := []accessTest{
+		{[]string{"dat.f $1, $2"}, []access{}},
+		{[]string{"mov.a $1, $2"}, []access{rd(1, FieldA, OperandA), wr(2, FieldA, OperandB)}},
+		{[]string{"mov.x $1, $2"}, []access{
+			rd(1, FieldA, OperandA), rd(1, FieldB, OperandA), wr(2, FieldB, OperandB), wr(2, FieldA, OperandB),
+		}},
+		{[]string{"add.f $1, $2"}, []access{
+			rd(1, FieldA, OperandA), rd(1, FieldB, OperandA), rd(2, FieldA, OperandB), rd(2, FieldB, OperandB),
+			wr(2, FieldA, OperandB), wr(2, FieldB, OperandB),
+		}},
+		{[]string{"sub.ab $1, $2"}, []access{rd(1, FieldA, OperandA), rd(2, FieldB, OperandB), wr(2, FieldB, OperandB)}},
+		{[]string{"mul.ba $1, $2"}, []access{rd(1, FieldB, OperandA), rd(2, FieldA, OperandB), wr(2, FieldA, OperandB)}},
+		{[]string{"div.f $1, $2", "dat.f $0, $2"}, []access{
+			rd(1, FieldA, OperandA), rd(1, FieldB, OperandA), rd(2, FieldA, OperandB), rd(2, FieldB, OperandB),
+			wr(2, FieldB, OperandB),
+		}},
+		{[]string{"mod.x $1, $2", "dat.f $3, $0"}, []access{
+			rd(1, FieldA, OperandA), rd(1, FieldB, OperandA), rd(2, FieldB, OperandB), rd(2, FieldA, OperandB),
+			wr(2, FieldB, OperandB),
+		}},
+		{[]string{"jmp.b $1, $2"}, []access{}},
+		{[]string{"jmz.b $1, $2"}, []access{rd(2, FieldB, OperandB)}},
+		{[]string{"jmn.f $1, $2"}, []access{rd(2, FieldA, OperandB), rd(2, FieldB, OperandB)}},
+		{[]string{"djn.ab $1, $2"}, []access{dec(2, FieldB, OperandB)}},
+		{[]string{"djn.i $1, $2"}, []access{dec(2, FieldA, OperandB), dec(2, FieldB, OperandB)}},
+		{[]string{"cmp.i $1, $2"}, []access{rd(1, FieldInstruction, OperandA), rd(2, FieldInstruction, OperandB)}},
+		{[]string{"seq.a $1, $2"}, []access{rd(1, FieldA, OperandA), rd(2, FieldA, OperandB)}},
+		{[]string{"sne.x $1, $2"}, []access{
+			rd(1, FieldA, OperandA), rd(1, FieldB, OperandA), rd(2, FieldB, OperandB), rd(2, FieldA, OperandB),
+		}},
+		{[]string{"slt.i $1, $2"}, []access{
+			rd(1, FieldA, OperandA), rd(1, FieldB, OperandA), rd(2, FieldA, OperandB), rd(2, FieldB, OperandB),
+		}},
+		{[]string{"spl.b $1, $2"}, []access{}},
+		{[]string{"nop.f $1, $2"}, []access{}},
+	}
+	runAccessTests(t, "opcodes", tests)
+}
+
+func TestAccessLimitPointerReads(t *testing.T) {
+	config := NewQuickConfig(ICWS94, 16, 16, 1, 16)
+	config.ReadLimit = 4
+	config.Distance = 0
+	sim, err := newReportSim(config)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{
+		parseTestInstruction(t, "mov.i $0, @3", 16),
+	}})
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	reports := reportRecorder{}
+	sim.AddReporter(&reports)
+	sim.RunCycle()
+
+	// the pointer is read through both the read and write folded offsets
+	reads := []Address{}
+	for _, r := range reports {
+		if r.Type == WarriorRead && r.Operand == OperandB {
+			reads = append(reads, r.Address)
+		}
+	}
+	require.Equal(t, []Address{15, 3}, reads)
+}
@@ -65,7 +65,12 @@ func (o OpCode) String() string {
 		return "DJN"
 	case SPL:
 		return "SPL"
+	case NOP:
+		return "NOP"
 	default:
+		if h, ok := lookupOpcodeCode(o); ok {
+			return strings.ToUpper(h.Name())
+		}
 		return "???"
 	}
 }
@@ -164,7 +169,7 @@ func getOpMode(opModeStr string) (OpMode, error) {
 	}
 }
 
-func getOp94(op string) (OpCode, OpMode, error) {
+func getOp94(op string, opcodes opcodeSet) (OpCode, OpMode, error) {
 	fields := strings.Split(op, ".")
 	if len(fields) != 2 {
 		return 0, 0, fmt.Errorf("invalid op: '%s'", op)
@@ -172,7 +177,11 @@ func getOp94(op string) (OpCode, OpMode, error) {
 
 	code, err := getOpCode(fields[0])
 	if err != nil {
-		return 0, 0, err
+		h, ok := opcodes.byName(fields[0])
+		if !ok {
+			return 0, 0, err
+		}
+		code = h.Code()
 	}
 
 	opmode, err := getOpMode(fields[1])
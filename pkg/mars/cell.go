@@ -0,0 +1,47 @@
+package mars
+
+// MaxCoreSize is the largest supported core size. Core cells store fields in
+// 32 bits, and sums of two fields must not overflow.
+const MaxCoreSize = 1 << 31
+
+// cell is the compact storage of an Instruction in the core, using 12 bytes
+// instead of the 24 used by Instruction. Field values are always less than
+// the core size.
+type cell struct {
+	A      uint32
+	B      uint32
+	Op     OpCode
+	OpMode OpMode
+	AMode  AddressMode
+	BMode  AddressMode
+}
+
+// newCell returns the cell storing an Instruction with fields less than
+// the core size
+func newCell(i Instruction) cell {
+	return cell{
+		A:      uint32(i.A),
+		B:      uint32(i.B),
+		Op:     i.Op,
+		OpMode: i.OpMode,
+		AMode:  i.AMode,
+		BMode:  i.BMode,
+	}
+}
+
+// instruction returns the Instruction stored in a cell
+func (c cell) instruction() Instruction {
+	return Instruction{
+		Op:     c.Op,
+		OpMode: c.OpMode,
+		A:      Address(c.A),
+		AMode:  c.AMode,
+		B:      Address(c.B),
+		BMode:  c.BMode,
+	}
+}
+
+// mulm returns (a * b) % s.m without overflowing 32 bits
+func (s *simState) mulm(a, b uint32) uint32 {
+	return uint32(uint64(a) * uint64(b) % uint64(s.m32))
+}
@@ -0,0 +1,64 @@
+package mars
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCellSize(t *testing.T) {
+	require.Equal(t, uintptr(12), unsafe.Sizeof(cell{}))
+}
+
+func TestCellInstruction(t *testing.T) {
+	inst := Instruction{Op: DJN, OpMode: BA, AMode: A_INCREMENT, A: MaxCoreSize - 1, BMode: B_DECREMENT, B: 7}
+	require.Equal(t, inst, newCell(inst).instruction())
+}
+
+func TestCellMaxCoreSize(t *testing.T) {
+	config := NewQuickConfig(ICWS94, MaxCoreSize, 8, 10, 10)
+	require.NoError(t, config.Validate())
+	config.CoreSize = MaxCoreSize + 1
+	require.Error(t, config.Validate())
+}
+
+func TestCellLargeCoreArithmetic(t *testing.T) {
+	// products of fields near the maximum core size must not overflow
+	s := simState{m: MaxCoreSize, m32: MaxCoreSize}
+	require.Equal(t, uint32(1), s.mulm(MaxCoreSize-1, MaxCoreSize-1))
+}
+
+// benchmarkLayout copies and updates cells at a warrior-like stride through
+// a core, as a dwarf bombing a large core does
+func benchmarkLayout[T any](b *testing.B, core []T, update func(*T, uint32)) {
+	n := uint32(len(core))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := uint32(0)
+		for j := uint32(0); j < n; j++ {
+			p += 2667
+			if p >= n-1 {
+				p -= n - 1
+			}
+			core[p+1] = core[p]
+			update(&core[p], j)
+		}
+	}
+}
+
+// BenchmarkCoreLayout compares the Instruction layout the core used before
+// cells with the 12 byte cell layout on a core too large for the cache
+func BenchmarkCoreLayout(b *testing.B) {
+	const coreSize = 1 << 20
+	b.Run("Instruction", func(b *testing.B) {
+		benchmarkLayout(b, make([]Instruction, coreSize), func(inst *Instruction, v uint32) {
+			inst.B = Address(v)
+		})
+	})
+	b.Run("cell", func(b *testing.B) {
+		benchmarkLayout(b, make([]cell, coreSize), func(c *cell, v uint32) {
+			c.B = v
+		})
+	})
+}
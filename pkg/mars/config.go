@@ -3,14 +3,30 @@ package mars
 import "fmt"
 
 type SimulatorConfig struct {
-	Mode       SimulatorMode
-	CoreSize   Address
-	Processes  Address
-	Cycles     Address
-	ReadLimit  Address
-	WriteLimit Address
-	Length     Address
-	Distance   Address
+	Mode       SimulatorMode `json:"mode"`
+	CoreSize   Address       `json:"core_size"`
+	Processes  Address       `json:"processes"`
+	Cycles     Address       `json:"cycles"`
+	ReadLimit  Address       `json:"read_limit"`
+	WriteLimit Address       `json:"write_limit"`
+	Length     Address       `json:"length"`
+	Distance   Address       `json:"distance"`
+
+	// QueuePolicy selects what happens when a process is added to a full
+	// process queue
+	QueuePolicy QueuePolicy `json:"queue_policy"`
+
+	// Opcodes lists the names of registered op code extensions enabled
+	// for the simulator and load file parser
+	Opcodes []string `json:"opcodes,omitempty"`
+
+	// CoreFill selects how the core is initialized. FillInstruction is used
+	// by CoreFillInstruction, FillSeed by CoreFillRandom and CoreImage by
+	// CoreFillImage.
+	CoreFill        CoreFill    `json:"core_fill"`
+	FillInstruction Instruction `json:"fill_instruction"`
+	FillSeed        int64       `json:"fill_seed,omitempty"`
+	CoreImage       string      `json:"core_image,omitempty"`
 }
 
 func ConfigKOTH88() SimulatorConfig {
@@ -57,6 +73,9 @@ func (c SimulatorConfig) Validate() error {
 	if c.CoreSize < 3 {
 		return fmt.Errorf("the minimum core size is 3")
 	}
+	if c.CoreSize > MaxCoreSize {
+		return fmt.Errorf("the maximum core size is %d", MaxCoreSize)
+	}
 
 	if c.Processes < 1 {
 		return fmt.Errorf("invalid process limit")
@@ -81,5 +100,20 @@ func (c SimulatorConfig) Validate() error {
 		return fmt.Errorf("invalid distance")
 	}
 
+	if c.QueuePolicy > QueueDropOldest {
+		return fmt.Errorf("invalid queue policy")
+	}
+
+	if _, err := newOpcodeSet(c.Opcodes); err != nil {
+		return err
+	}
+
+	if c.CoreFill > CoreFillImage {
+		return fmt.Errorf("invalid core fill")
+	}
+	if c.CoreFill == CoreFillImage && c.CoreImage == "" {
+		return fmt.Errorf("missing core image file")
+	}
+
 	return nil
 }
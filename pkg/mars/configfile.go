@@ -0,0 +1,350 @@
+package mars
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigFormat selects the encoding of a configuration file
+type ConfigFormat uint8
+
+const (
+	ConfigJSON ConfigFormat = iota
+	ConfigTOML
+)
+
+var simulatorModeNames = map[SimulatorMode]string{
+	ICWS88: "icws88",
+	NOP94:  "nop94",
+	ICWS94: "icws94",
+}
+
+var queuePolicyNames = map[QueuePolicy]string{
+	QueueDropNew:    "drop-new",
+	QueueDropOldest: "drop-oldest",
+}
+
+func (m SimulatorMode) MarshalText() ([]byte, error) {
+	name, ok := simulatorModeNames[m]
+	if !ok {
+		return nil, fmt.Errorf("invalid simulator mode %d", m)
+	}
+	return []byte(name), nil
+}
+
+func (m *SimulatorMode) UnmarshalText(text []byte) error {
+	for mode, name := range simulatorModeNames {
+		if strings.EqualFold(string(text), name) {
+			*m = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid simulator mode '%s'", text)
+}
+
+func (p QueuePolicy) MarshalText() ([]byte, error) {
+	name, ok := queuePolicyNames[p]
+	if !ok {
+		return nil, fmt.Errorf("invalid queue policy %d", p)
+	}
+	return []byte(name), nil
+}
+
+func (p *QueuePolicy) UnmarshalText(text []byte) error {
+	for policy, name := range queuePolicyNames {
+		if strings.EqualFold(string(text), name) {
+			*p = policy
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid queue policy '%s'", text)
+}
+
+// configFormatForPath returns the format of a file by its extension
+func configFormatForPath(path string) (ConfigFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ConfigJSON, nil
+	case ".toml":
+		return ConfigTOML, nil
+	default:
+		return 0, fmt.Errorf("unknown config file extension '%s'", filepath.Ext(path))
+	}
+}
+
+// LoadConfig reads a configuration. Unset read and write limits default
+// to the core size, and the result is validated.
+func LoadConfig(r io.Reader, format ConfigFormat) (SimulatorConfig, error) {
+	var config SimulatorConfig
+	var err error
+	switch format {
+	case ConfigJSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&config)
+	case ConfigTOML:
+		config, err = decodeConfigTOML(r)
+	default:
+		err = fmt.Errorf("invalid config format")
+	}
+	if err != nil {
+		return SimulatorConfig{}, err
+	}
+
+	if config.ReadLimit == 0 {
+		config.ReadLimit = config.CoreSize
+	}
+	if config.WriteLimit == 0 {
+		config.WriteLimit = config.CoreSize
+	}
+
+	if err := config.Validate(); err != nil {
+		return SimulatorConfig{}, err
+	}
+	return config, nil
+}
+
+// SaveConfig writes a configuration
+func SaveConfig(w io.Writer, config SimulatorConfig, format ConfigFormat) error {
+	switch format {
+	case ConfigJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(config)
+	case ConfigTOML:
+		return encodeConfigTOML(w, config)
+	default:
+		return fmt.Errorf("invalid config format")
+	}
+}
+
+// LoadConfigFile reads a configuration from a .json or .toml file
+func LoadConfigFile(path string) (SimulatorConfig, error) {
+	format, err := configFormatForPath(path)
+	if err != nil {
+		return SimulatorConfig{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return SimulatorConfig{}, err
+	}
+	defer f.Close()
+	return LoadConfig(f, format)
+}
+
+// SaveConfigFile writes a configuration to a .json or .toml file
+func SaveConfigFile(path string, config SimulatorConfig) error {
+	format, err := configFormatForPath(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := SaveConfig(f, config, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// encodeConfigTOML writes the config as flat TOML key/value pairs using the
+// same keys as the JSON encoding
+func encodeConfigTOML(w io.Writer, c SimulatorConfig) error {
+	mode, err := c.Mode.MarshalText()
+	if err != nil {
+		return err
+	}
+	policy, err := c.QueuePolicy.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "mode = %q\n", mode)
+	fmt.Fprintf(bw, "core_size = %d\n", c.CoreSize)
+	fmt.Fprintf(bw, "processes = %d\n", c.Processes)
+	fmt.Fprintf(bw, "cycles = %d\n", c.Cycles)
+	fmt.Fprintf(bw, "read_limit = %d\n", c.ReadLimit)
+	fmt.Fprintf(bw, "write_limit = %d\n", c.WriteLimit)
+	fmt.Fprintf(bw, "length = %d\n", c.Length)
+	fmt.Fprintf(bw, "distance = %d\n", c.Distance)
+	fmt.Fprintf(bw, "queue_policy = %q\n", policy)
+	if c.CoreFill != CoreFillZero {
+		fill, err := c.CoreFill.MarshalText()
+		if err != nil {
+			return err
+		}
+		inst, _ := c.FillInstruction.MarshalText()
+		fmt.Fprintf(bw, "core_fill = %q\n", fill)
+		fmt.Fprintf(bw, "fill_instruction = %q\n", inst)
+		fmt.Fprintf(bw, "fill_seed = %d\n", c.FillSeed)
+		fmt.Fprintf(bw, "core_image = %q\n", c.CoreImage)
+	}
+	if len(c.Opcodes) > 0 {
+		quoted := make([]string, len(c.Opcodes))
+		for i, name := range c.Opcodes {
+			quoted[i] = strconv.Quote(name)
+		}
+		fmt.Fprintf(bw, "opcodes = [%s]\n", strings.Join(quoted, ", "))
+	}
+	return bw.Flush()
+}
+
+// decodeConfigTOML reads the subset of TOML written by encodeConfigTOML:
+// comments and key/value pairs with integer, string and string array
+// values
+func decodeConfigTOML(r io.Reader) (SimulatorConfig, error) {
+	var config SimulatorConfig
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return SimulatorConfig{}, fmt.Errorf("line %d: expected key = value", lineNum)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case "mode":
+			err = decodeTOMLText(value, &config.Mode)
+		case "core_size":
+			config.CoreSize, err = decodeTOMLAddress(value)
+		case "processes":
+			config.Processes, err = decodeTOMLAddress(value)
+		case "cycles":
+			config.Cycles, err = decodeTOMLAddress(value)
+		case "read_limit":
+			config.ReadLimit, err = decodeTOMLAddress(value)
+		case "write_limit":
+			config.WriteLimit, err = decodeTOMLAddress(value)
+		case "length":
+			config.Length, err = decodeTOMLAddress(value)
+		case "distance":
+			config.Distance, err = decodeTOMLAddress(value)
+		case "queue_policy":
+			err = decodeTOMLText(value, &config.QueuePolicy)
+		case "opcodes":
+			config.Opcodes, err = decodeTOMLStrings(value)
+		case "core_fill":
+			err = decodeTOMLText(value, &config.CoreFill)
+		case "fill_instruction":
+			err = decodeTOMLText(value, &config.FillInstruction)
+		case "fill_seed":
+			config.FillSeed, err = decodeTOMLInt(value)
+		case "core_image":
+			config.CoreImage, err = decodeTOMLString(stripTOMLComment(value))
+		default:
+			err = fmt.Errorf("unknown key '%s'", key)
+		}
+		if err != nil {
+			return SimulatorConfig{}, fmt.Errorf("line %d: %s", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return SimulatorConfig{}, err
+	}
+
+	return config, nil
+}
+
+// stripTOMLComment removes a trailing comment from a value that does not
+// contain quoted '#' characters
+func stripTOMLComment(value string) string {
+	if i := strings.LastIndex(value, "#"); i >= 0 && !strings.Contains(value[i:], "\"") {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+func decodeTOMLAddress(value string) (Address, error) {
+	val, err := strconv.ParseUint(strings.ReplaceAll(stripTOMLComment(value), "_", ""), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer '%s'", value)
+	}
+	return Address(val), nil
+}
+
+func decodeTOMLInt(value string) (int64, error) {
+	val, err := strconv.ParseInt(strings.ReplaceAll(stripTOMLComment(value), "_", ""), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer '%s'", value)
+	}
+	return val, nil
+}
+
+func decodeTOMLString(value string) (string, error) {
+	s, err := strconv.Unquote(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid string '%s'", value)
+	}
+	return s, nil
+}
+
+func decodeTOMLText(value string, out interface{ UnmarshalText([]byte) error }) error {
+	s, err := decodeTOMLString(stripTOMLComment(value))
+	if err != nil {
+		return err
+	}
+	return out.UnmarshalText([]byte(s))
+}
+
+// decodeTOMLStrings reads an array of basic strings, which may contain
+// commas and escaped quotes
+func decodeTOMLStrings(value string) ([]string, error) {
+	value = stripTOMLComment(value)
+	if len(value) < 2 || value[0] != '[' || value[len(value)-1] != ']' {
+		return nil, fmt.Errorf("invalid array '%s'", value)
+	}
+	rest := strings.TrimSpace(value[1 : len(value)-1])
+
+	var out []string
+	for rest != "" {
+		if rest[0] != '"' {
+			return nil, fmt.Errorf("invalid array '%s'", value)
+		}
+		// find the closing quote, skipping escaped characters
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return nil, fmt.Errorf("invalid array '%s'", value)
+		}
+		s, err := decodeTOMLString(rest[:end+1])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+
+		rest = strings.TrimSpace(rest[end+1:])
+		if rest == "" {
+			break
+		}
+		if rest[0] != ',' {
+			return nil, fmt.Errorf("invalid array '%s'", value)
+		}
+		// a trailing comma is allowed
+		rest = strings.TrimSpace(rest[1:])
+	}
+	return out, nil
+}
@@ -0,0 +1,104 @@
+package mars
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	config := NewQuickConfig(ICWS88, 800, 64, 8000, 20)
+	config.ReadLimit = 400
+	config.QueuePolicy = QueueDropOldest
+
+	for _, format := range []ConfigFormat{ConfigJSON, ConfigTOML} {
+		buf := &bytes.Buffer{}
+		require.NoError(t, SaveConfig(buf, config, format))
+		out, err := LoadConfig(buf, format)
+		require.NoError(t, err)
+		require.Equal(t, config, out)
+	}
+}
+
+func TestConfigFileRoundTrip(t *testing.T) {
+	config, err := Preset("tiny")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	for _, name := range []string{"tiny.json", "tiny.toml"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, SaveConfigFile(path, config))
+		out, err := LoadConfigFile(path)
+		require.NoError(t, err)
+		require.Equal(t, config, out)
+	}
+
+	require.Error(t, SaveConfigFile(filepath.Join(dir, "tiny.yaml"), config))
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	input := `# nano hill
+mode = "icws94"
+core_size = 80   # cells
+processes = 80
+cycles = 800
+length = 5
+distance = 5
+queue_policy = "drop-new"
+`
+	config, err := LoadConfig(strings.NewReader(input), ConfigTOML)
+	require.NoError(t, err)
+	require.Equal(t, NewQuickConfig(ICWS94, 80, 80, 800, 5), config)
+
+	invalid := []string{
+		"core_size 80\n",
+		"size = 80\n",
+		"core_size = \"80\"\n",
+		"mode = \"icws95\"\n",
+		"opcodes = \"xch\"\n",
+	}
+	for _, in := range invalid {
+		_, err := LoadConfig(strings.NewReader(in), ConfigTOML)
+		require.Error(t, err, in)
+	}
+}
+
+func TestDecodeTOMLStrings(t *testing.T) {
+	tests := []struct {
+		in  string
+		out []string
+	}{
+		{`[]`, nil},
+		{`["xch"]`, []string{"xch"}},
+		{`[ "xch" , "hlt", ]  # extensions`, []string{"xch", "hlt"}},
+		{`["a,b", "c"]`, []string{"a,b", "c"}},
+		{`["say \"hi\", ok"]`, []string{`say "hi", ok`}},
+	}
+	for _, test := range tests {
+		out, err := decodeTOMLStrings(test.in)
+		require.NoError(t, err, test.in)
+		require.Equal(t, test.out, out, test.in)
+	}
+
+	for _, in := range []string{`"xch"`, `[xch]`, `["xch" "hlt"]`, `["xch`, `["xch",,]`} {
+		_, err := decodeTOMLStrings(in)
+		require.Error(t, err, in)
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	input := `{"mode": "icws94", "core_size": 8000, "processes": 8, "cycles": 80000, "length": 200, "distance": 200}`
+	config, err := LoadConfig(strings.NewReader(input), ConfigJSON)
+	require.NoError(t, err)
+	require.Equal(t, NewQuickConfig(ICWS94, 8000, 8, 80000, 200), config)
+
+	_, err = LoadConfig(strings.NewReader(`{"core_size": 8000, "unknown": 1}`), ConfigJSON)
+	require.Error(t, err)
+
+	// fails validation
+	_, err = LoadConfig(strings.NewReader(`{"core_size": 2}`), ConfigJSON)
+	require.Error(t, err)
+}
@@ -0,0 +1,188 @@
+package mars
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CoreFill selects how the core is initialized before warriors are spawned
+type CoreFill uint8
+
+const (
+	// CoreFillZero fills the core with DAT.F $0, $0
+	CoreFillZero CoreFill = iota
+	// CoreFillInstruction fills the core with SimulatorConfig.FillInstruction
+	CoreFillInstruction
+	// CoreFillRandom fills the core with random instructions generated from
+	// SimulatorConfig.FillSeed
+	CoreFillRandom
+	// CoreFillImage loads the core from the load file named by
+	// SimulatorConfig.CoreImage, leaving the remaining addresses zeroed
+	CoreFillImage
+)
+
+var coreFillNames = map[CoreFill]string{
+	CoreFillZero:        "zero",
+	CoreFillInstruction: "instruction",
+	CoreFillRandom:      "random",
+	CoreFillImage:       "image",
+}
+
+func (f CoreFill) String() string {
+	if name, ok := coreFillNames[f]; ok {
+		return name
+	}
+	return "?"
+}
+
+func (f CoreFill) MarshalText() ([]byte, error) {
+	name, ok := coreFillNames[f]
+	if !ok {
+		return nil, fmt.Errorf("invalid core fill %d", f)
+	}
+	return []byte(name), nil
+}
+
+func (f *CoreFill) UnmarshalText(text []byte) error {
+	for fill, name := range coreFillNames {
+		if strings.EqualFold(string(text), name) {
+			*f = fill
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid core fill '%s'", text)
+}
+
+// MarshalText encodes an instruction as "OP.MODE <mode><A>, <mode><B>"
+// with unsigned field values
+func (i Instruction) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%s.%s %s%d, %s%d", i.Op, i.OpMode, i.AMode, i.A, i.BMode, i.B)), nil
+}
+
+// UnmarshalText decodes an instruction encoded by MarshalText. Field values
+// must be unsigned.
+func (i *Instruction) UnmarshalText(text []byte) error {
+	fields := strings.Fields(strings.ReplaceAll(string(text), ",", " "))
+	if len(fields) != 3 {
+		return fmt.Errorf("invalid instruction '%s'", text)
+	}
+
+	op, opMode, err := getOp94(fields[0], nil)
+	if err != nil {
+		if h, ok := LookupOpcode(strings.Split(fields[0], ".")[0]); ok {
+			op, opMode, err = getOp94(fields[0], opcodeSet{h.Code(): h})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	parseOperand := func(s string) (AddressMode, Address, error) {
+		if len(s) < 2 {
+			return 0, 0, fmt.Errorf("invalid operand '%s'", s)
+		}
+		mode, err := getAddressMode(s[:1])
+		if err != nil {
+			return 0, 0, err
+		}
+		val, err := strconv.ParseUint(s[1:], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid field value '%s'", s[1:])
+		}
+		return mode, Address(val), nil
+	}
+
+	aMode, a, err := parseOperand(fields[1])
+	if err != nil {
+		return err
+	}
+	bMode, b, err := parseOperand(fields[2])
+	if err != nil {
+		return err
+	}
+
+	*i = Instruction{Op: op, OpMode: opMode, AMode: aMode, A: a, BMode: bMode, B: b}
+	return nil
+}
+
+// newCoreFill returns the contents of the core before warriors are spawned,
+// or nil if the core is filled with a single instruction
+func newCoreFill(config SimulatorConfig, opcodes opcodeSet) ([]cell, error) {
+	m := config.CoreSize
+
+	switch config.CoreFill {
+	case CoreFillRandom:
+		r := rand.New(rand.NewSource(config.FillSeed))
+		fill := make([]cell, m)
+		for i := range fill {
+			fill[i] = cell{
+				Op:     OpCode(r.Intn(int(NOP) + 1)),
+				OpMode: OpMode(r.Intn(int(I) + 1)),
+				AMode:  AddressMode(r.Intn(int(B_INCREMENT) + 1)),
+				A:      uint32(r.Int63n(int64(m))),
+				BMode:  AddressMode(r.Intn(int(B_INCREMENT) + 1)),
+				B:      uint32(r.Int63n(int64(m))),
+			}
+		}
+		return fill, nil
+
+	case CoreFillImage:
+		f, err := os.Open(config.CoreImage)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		data, err := parseLoadFile94(f, m, opcodes)
+		if err != nil {
+			return nil, fmt.Errorf("core image: %s", err)
+		}
+		if Address(len(data.Code)) > m {
+			return nil, fmt.Errorf("core image has %d instructions, core size is %d", len(data.Code), m)
+		}
+		fill := make([]cell, m)
+		for i, inst := range data.Code {
+			fill[i] = newCell(inst)
+		}
+		return fill, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// fillCore initializes the core according to the config
+func (s *simState) fillCore() {
+	switch {
+	case s.fill != nil:
+		copy(s.mem, s.fill)
+	case s.config.CoreFill == CoreFillInstruction:
+		inst := s.config.FillInstruction
+		inst.A %= s.m
+		inst.B %= s.m
+		c := newCell(inst)
+		for i := range s.mem {
+			s.mem[i] = c
+		}
+	default:
+		clear(s.mem)
+	}
+}
+
+// SaveCoreImage writes the core of a simulator as a load file that can be
+// used as a CoreFillImage
+func SaveCoreImage(w io.Writer, sim Simulator) error {
+	bw := bufio.NewWriter(w)
+	m := sim.CoreSize()
+	for a := Address(0); a < m; a++ {
+		inst := sim.GetMem(a)
+		fmt.Fprintf(bw, "%s.%s %s %d, %s %d\n", inst.Op, inst.OpMode,
+			inst.AMode, signedAddress(inst.A, m), inst.BMode, signedAddress(inst.B, m))
+	}
+	return bw.Flush()
+}
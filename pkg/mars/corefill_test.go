@@ -0,0 +1,148 @@
+package mars
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var simConstructors = []func(SimulatorConfig) (Simulator, error){NewSimulator, NewFastSimulator}
+
+func coreContents(sim Simulator) []Instruction {
+	out := make([]Instruction, sim.CoreSize())
+	for i := range out {
+		out[i] = sim.GetMem(Address(i))
+	}
+	return out
+}
+
+func TestCoreFillInstruction(t *testing.T) {
+	config := NewQuickConfig(NOP94, 80, 80, 800, 5)
+	config.CoreFill = CoreFillInstruction
+	config.FillInstruction = Instruction{Op: JMP, OpMode: B, AMode: DIRECT, A: 81, BMode: DIRECT, B: 0}
+
+	for _, newSim := range simConstructors {
+		sim, err := newSim(config)
+		require.NoError(t, err)
+
+		expected := Instruction{Op: JMP, OpMode: B, AMode: DIRECT, A: 1, BMode: DIRECT, B: 0}
+		for a := Address(0); a < sim.CoreSize(); a++ {
+			require.Equal(t, expected, sim.GetMem(a))
+		}
+
+		_, err = sim.AddWarrior(makeDwarfData())
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(0, 0))
+		require.NotEqual(t, expected, sim.GetMem(0))
+		sim.Reset()
+		require.Equal(t, expected, sim.GetMem(0))
+	}
+}
+
+func TestCoreFillRandom(t *testing.T) {
+	config := NewQuickConfig(NOP94, 800, 800, 8000, 20)
+	config.CoreFill = CoreFillRandom
+	config.FillSeed = 42
+
+	for _, newSim := range simConstructors {
+		sim1, err := newSim(config)
+		require.NoError(t, err)
+		sim2, err := newSim(config)
+		require.NoError(t, err)
+
+		core := coreContents(sim1)
+		require.Equal(t, core, coreContents(sim2))
+		require.NotEqual(t, make([]Instruction, len(core)), core)
+
+		_, err = sim1.AddWarrior(makeDwarfData())
+		require.NoError(t, err)
+		require.NoError(t, sim1.SpawnWarrior(0, 0))
+		sim1.RunCycles(100)
+		require.NotEqual(t, core, coreContents(sim1))
+		sim1.Reset()
+		require.Equal(t, core, coreContents(sim1))
+
+		config.FillSeed = 43
+		sim3, err := newSim(config)
+		require.NoError(t, err)
+		require.NotEqual(t, core, coreContents(sim3))
+		config.FillSeed = 42
+	}
+}
+
+func TestCoreFillImage(t *testing.T) {
+	config := NewQuickConfig(NOP94, 800, 800, 8000, 20)
+	config.CoreFill = CoreFillRandom
+	config.FillSeed = 7
+
+	src, err := NewSimulator(config)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "core.red")
+	buf := &bytes.Buffer{}
+	require.NoError(t, SaveCoreImage(buf, src))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	config.CoreFill = CoreFillImage
+	config.CoreImage = path
+	for _, newSim := range simConstructors {
+		sim, err := newSim(config)
+		require.NoError(t, err)
+		require.Equal(t, coreContents(src), coreContents(sim))
+	}
+
+	// an image smaller than the core leaves the rest zeroed
+	require.NoError(t, os.WriteFile(path, []byte("JMP.B $ -1, $ 0\n"), 0o644))
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	require.Equal(t, Instruction{Op: JMP, OpMode: B, AMode: DIRECT, A: 799, BMode: DIRECT}, sim.GetMem(0))
+	require.Equal(t, Instruction{}, sim.GetMem(1))
+
+	config.CoreImage = filepath.Join(t.TempDir(), "missing.red")
+	_, err = NewSimulator(config)
+	require.Error(t, err)
+
+	config.CoreImage = ""
+	require.Error(t, config.Validate())
+}
+
+func TestInstructionText(t *testing.T) {
+	inst := Instruction{Op: MOV, OpMode: I, AMode: A_INDIRECT, A: 3, BMode: B_DECREMENT, B: 7999}
+	text, err := inst.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "MOV.I *3, <7999", string(text))
+
+	var out Instruction
+	require.NoError(t, out.UnmarshalText(text))
+	require.Equal(t, inst, out)
+
+	require.Error(t, out.UnmarshalText([]byte("MOV.I *3")))
+	require.Error(t, out.UnmarshalText([]byte("FOO.I $0, $0")))
+	require.Error(t, out.UnmarshalText([]byte("MOV.I $-1, $0")))
+}
+
+func TestCoreFillConfigRoundTrip(t *testing.T) {
+	config := NewQuickConfig(NOP94, 800, 800, 8000, 20)
+	config.CoreFill = CoreFillInstruction
+	config.FillInstruction = Instruction{Op: SPL, OpMode: B, AMode: DIRECT, A: 0, BMode: IMMEDIATE, B: 1}
+	config.FillSeed = -5
+
+	for _, format := range []ConfigFormat{ConfigJSON, ConfigTOML} {
+		buf := &bytes.Buffer{}
+		require.NoError(t, SaveConfig(buf, config, format))
+		out, err := LoadConfig(buf, format)
+		require.NoError(t, err)
+		require.Equal(t, config, out)
+	}
+
+	sim, err := NewSimulator(config)
+	require.NoError(t, err)
+	data, err := sim.Snapshot().MarshalBinary()
+	require.NoError(t, err)
+	decoded := &Snapshot{}
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, config, decoded.Config)
+}
@@ -0,0 +1,302 @@
+package mars
+
+type BreakpointType uint8
+
+const (
+	// BreakExec fires when a process executes an address in a range
+	BreakExec BreakpointType = iota
+	// BreakWatch fires when a field of an address is read or written
+	BreakWatch
+	// BreakProcesses fires when a warrior's process count crosses a threshold
+	BreakProcesses
+	// BreakOpcode fires when an instruction with an op code is executed
+	BreakOpcode
+)
+
+// WatchAccess is a bit set of the accesses a watchpoint fires on
+type WatchAccess uint8
+
+const (
+	// WatchRead fires on every read of the field
+	WatchRead WatchAccess = 1 << iota
+	// WatchWrite fires on every write of the field, even if the written
+	// value is the same
+	WatchWrite
+	// WatchChange fires on writes that change the value of the field
+	WatchChange
+)
+
+// Breakpoint describes a condition registered on a Debugger. Only the
+// fields relevant to Type are used.
+type Breakpoint struct {
+	ID   int
+	Type BreakpointType
+
+	// Start and End are the inclusive address range of BreakExec and the
+	// address of BreakWatch in Start
+	Start Address
+	End   Address
+
+	// Field and Access select what BreakWatch fires on
+	Field  Field
+	Access WatchAccess
+
+	// WarriorIndex and Processes select the warrior and threshold of
+	// BreakProcesses. It fires when the process count rises to at least
+	// Processes, or falls to at most Processes if Below is set.
+	WarriorIndex int
+	Processes    Address
+	Below        bool
+
+	// Op is the op code of BreakOpcode
+	Op OpCode
+}
+
+// BreakpointHit records a Breakpoint firing
+type BreakpointHit struct {
+	Breakpoint   Breakpoint
+	Cycle        int
+	WarriorIndex int
+	Address      Address
+}
+
+// Debugger implements a Reporter that checks registered breakpoints and
+// watchpoints against the reports of a simulator. Run stops the simulation
+// at the end of the cycle in which a breakpoint fires, and can be called
+// again to resume.
+type Debugger struct {
+	sim         ReportingSimulator
+	breakpoints []Breakpoint
+	nextID      int
+	hits        []BreakpointHit
+
+	// values of watched addresses, used to detect which fields a write
+	// changed for WatchChange
+	watched map[Address]Instruction
+
+	// whether each process threshold was met at the end of the last cycle
+	thresholdMet map[int]bool
+}
+
+// NewDebugger creates a Debugger and adds it as a reporter to sim
+func NewDebugger(sim ReportingSimulator) *Debugger {
+	d := &Debugger{
+		sim:          sim,
+		watched:      make(map[Address]Instruction),
+		thresholdMet: make(map[int]bool),
+	}
+	sim.AddReporter(d)
+	return d
+}
+
+func (d *Debugger) add(bp Breakpoint) int {
+	d.nextID++
+	bp.ID = d.nextID
+	d.breakpoints = append(d.breakpoints, bp)
+	return bp.ID
+}
+
+// AddBreakpoint adds a breakpoint on executing address a and returns its ID
+func (d *Debugger) AddBreakpoint(a Address) int {
+	return d.AddBreakpointRange(a, a)
+}
+
+// AddBreakpointRange adds a breakpoint on executing any address from start
+// to end inclusive and returns its ID
+func (d *Debugger) AddBreakpointRange(start, end Address) int {
+	m := d.sim.CoreSize()
+	return d.add(Breakpoint{Type: BreakExec, Start: start % m, End: end % m})
+}
+
+// AddWatchpoint adds a watchpoint on accesses to a field of address a and
+// returns its ID
+func (d *Debugger) AddWatchpoint(a Address, field Field, access WatchAccess) int {
+	a = a % d.sim.CoreSize()
+	d.watched[a] = d.sim.GetMem(a)
+	return d.add(Breakpoint{Type: BreakWatch, Start: a, End: a, Field: field, Access: access})
+}
+
+// AddProcessThreshold adds a breakpoint on the process count of warrior wi
+// reaching n, or falling to n if below is set, and returns its ID
+func (d *Debugger) AddProcessThreshold(wi int, n Address, below bool) int {
+	id := d.add(Breakpoint{Type: BreakProcesses, WarriorIndex: wi, Processes: n, Below: below})
+	d.thresholdMet[id] = d.processThresholdMet(d.breakpoints[len(d.breakpoints)-1])
+	return id
+}
+
+// AddOpcodeBreakpoint adds a breakpoint on executing op and returns its ID
+func (d *Debugger) AddOpcodeBreakpoint(op OpCode) int {
+	return d.add(Breakpoint{Type: BreakOpcode, Op: op})
+}
+
+// Remove removes the breakpoint with the given ID and returns true if it
+// was found
+func (d *Debugger) Remove(id int) bool {
+	for i, bp := range d.breakpoints {
+		if bp.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			delete(d.thresholdMet, id)
+			if bp.Type == BreakWatch && !d.isWatched(bp.Start) {
+				delete(d.watched, bp.Start)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// isWatched returns true if a watchpoint is registered on address a
+func (d *Debugger) isWatched(a Address) bool {
+	for _, bp := range d.breakpoints {
+		if bp.Type == BreakWatch && bp.Start == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Breakpoints returns the registered breakpoints
+func (d *Debugger) Breakpoints() []Breakpoint {
+	out := make([]Breakpoint, len(d.breakpoints))
+	copy(out, d.breakpoints)
+	return out
+}
+
+// Hits returns the breakpoints that fired during the last call to Run
+func (d *Debugger) Hits() []BreakpointHit {
+	return d.hits
+}
+
+// Run runs the simulation until a breakpoint fires, returning
+// StopBreakpoint, or until the simulation stops for another reason
+func (d *Debugger) Run() StopReason {
+	d.hits = nil
+	reason := d.sim.RunUntil(func(Simulator) bool {
+		return len(d.hits) > 0
+	})
+	if reason == StopPredicate {
+		return StopBreakpoint
+	}
+	return reason
+}
+
+func (d *Debugger) hit(bp Breakpoint, report Report) {
+	d.hits = append(d.hits, BreakpointHit{
+		Breakpoint:   bp,
+		Cycle:        d.sim.CycleCount(),
+		WarriorIndex: report.WarriorIndex,
+		Address:      report.Address,
+	})
+}
+
+func inRange(a, start, end Address) bool {
+	if start <= end {
+		return a >= start && a <= end
+	}
+	// ranges may wrap around the end of the core
+	return a >= start || a <= end
+}
+
+func (d *Debugger) processThresholdMet(bp Breakpoint) bool {
+	w := d.sim.GetWarrior(bp.WarriorIndex)
+	if w == nil || !w.Alive() {
+		return false
+	}
+	if bp.Below {
+		return w.ThreadCount() <= bp.Processes
+	}
+	return w.ThreadCount() >= bp.Processes
+}
+
+// fieldMatches returns true if an access to field touches the watched field
+func fieldMatches(watched, field Field) bool {
+	return watched == field || watched == FieldInstruction || field == FieldInstruction
+}
+
+// watchFieldChanged returns true if a write to a changed the watched field
+// from its saved value
+func (d *Debugger) watchFieldChanged(a Address, field Field) bool {
+	old := d.watched[a]
+	cur := d.sim.GetMem(a)
+	switch field {
+	case FieldA:
+		return old.A != cur.A
+	case FieldB:
+		return old.B != cur.B
+	default:
+		return old != cur
+	}
+}
+
+func (d *Debugger) Report(report Report) {
+	switch report.Type {
+	case SimReset, SimRestore, WarriorSpawn:
+		for a := range d.watched {
+			d.watched[a] = d.sim.GetMem(a)
+		}
+		for _, bp := range d.breakpoints {
+			if bp.Type == BreakProcesses {
+				d.thresholdMet[bp.ID] = d.processThresholdMet(bp)
+			}
+		}
+
+	case WarriorTaskPop:
+		for _, bp := range d.breakpoints {
+			switch bp.Type {
+			case BreakExec:
+				if inRange(report.Address, bp.Start, bp.End) {
+					d.hit(bp, report)
+				}
+			case BreakOpcode:
+				if d.sim.GetMem(report.Address).Op == bp.Op {
+					d.hit(bp, report)
+				}
+			}
+		}
+
+	case WarriorRead:
+		for _, bp := range d.breakpoints {
+			if bp.Type == BreakWatch && bp.Access&WatchRead != 0 && bp.Start == report.Address && fieldMatches(bp.Field, report.Field) {
+				d.hit(bp, report)
+			}
+		}
+
+	case WarriorWrite, WarriorIncrement, WarriorDecrement:
+		if _, ok := d.watched[report.Address]; !ok {
+			return
+		}
+		for _, bp := range d.breakpoints {
+			if bp.Type != BreakWatch || bp.Start != report.Address || !fieldMatches(bp.Field, report.Field) {
+				continue
+			}
+			if bp.Access&WatchWrite != 0 || (bp.Access&WatchChange != 0 && d.watchFieldChanged(report.Address, bp.Field)) {
+				d.hit(bp, report)
+			}
+		}
+		// only save the reported field so reports of other fields written
+		// by the same instruction are still detected
+		cur := d.sim.GetMem(report.Address)
+		saved := d.watched[report.Address]
+		switch report.Field {
+		case FieldA:
+			saved.A = cur.A
+		case FieldB:
+			saved.B = cur.B
+		default:
+			saved = cur
+		}
+		d.watched[report.Address] = saved
+
+	case CycleEnd:
+		for _, bp := range d.breakpoints {
+			if bp.Type != BreakProcesses {
+				continue
+			}
+			met := d.processThresholdMet(bp)
+			if met && !d.thresholdMet[bp.ID] {
+				d.hit(bp, Report{Type: report.Type, WarriorIndex: bp.WarriorIndex})
+			}
+			d.thresholdMet[bp.ID] = met
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package mars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newDwarfDebugger(t *testing.T) (ReportingSimulator, *Debugger) {
+	sim, err := NewReportingSimulator(ConfigNOP94())
+	require.NoError(t, err)
+	d := NewDebugger(sim)
+	_, err = sim.AddWarrior(makeDwarfData())
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+	return sim, d
+}
+
+func TestDebuggerExecBreakpoint(t *testing.T) {
+	sim, d := newDwarfDebugger(t)
+	d.AddBreakpoint(2)
+
+	require.Equal(t, StopBreakpoint, d.Run())
+	require.Equal(t, 3, sim.CycleCount())
+	require.Len(t, d.Hits(), 1)
+	require.Equal(t, BreakExec, d.Hits()[0].Breakpoint.Type)
+	require.Equal(t, 2, d.Hits()[0].Cycle)
+	require.Equal(t, Address(2), d.Hits()[0].Address)
+
+	// resume until the next loop iteration
+	require.Equal(t, StopBreakpoint, d.Run())
+	require.Equal(t, 6, sim.CycleCount())
+}
+
+func TestDebuggerWatchpoint(t *testing.T) {
+	sim, d := newDwarfDebugger(t)
+	d.AddWatchpoint(7, FieldA, WatchChange)
+	id := d.AddWatchpoint(7, FieldB, WatchChange)
+
+	require.Equal(t, StopBreakpoint, d.Run())
+	require.Equal(t, 2, sim.CycleCount())
+	require.Len(t, d.Hits(), 1)
+	require.Equal(t, id, d.Hits()[0].Breakpoint.ID)
+	require.Equal(t, Address(7), d.Hits()[0].Address)
+}
+
+func TestDebuggerWatchWriteSameValue(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94())
+	require.NoError(t, err)
+	d := NewDebugger(sim)
+	// copies itself over the next instruction, which is identical
+	_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{
+		{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1},
+		{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	change := d.AddWatchpoint(1, FieldA, WatchChange)
+	write := d.AddWatchpoint(1, FieldA, WatchWrite)
+
+	require.Equal(t, StopBreakpoint, d.Run())
+	require.Equal(t, 1, sim.CycleCount())
+	require.Len(t, d.Hits(), 1)
+	require.Equal(t, write, d.Hits()[0].Breakpoint.ID)
+
+	// removing the last watchpoint on an address stops tracking it
+	require.True(t, d.Remove(write))
+	require.Contains(t, d.watched, Address(1))
+	require.True(t, d.Remove(change))
+	require.NotContains(t, d.watched, Address(1))
+}
+
+func TestDebuggerOpcodeBreakpoint(t *testing.T) {
+	sim, d := newDwarfDebugger(t)
+	id := d.AddOpcodeBreakpoint(MOV)
+
+	require.Equal(t, StopBreakpoint, d.Run())
+	require.Equal(t, 2, sim.CycleCount())
+
+	require.True(t, d.Remove(id))
+	require.False(t, d.Remove(id))
+	require.Equal(t, StopCycleLimit, d.Run())
+	require.Empty(t, d.Hits())
+}
+
+func TestDebuggerProcessThreshold(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94())
+	require.NoError(t, err)
+	d := NewDebugger(sim)
+	_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{
+		{Op: SPL, OpMode: B, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+		{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	d.AddProcessThreshold(0, 10, false)
+	require.Equal(t, StopBreakpoint, d.Run())
+	require.Equal(t, Address(10), sim.GetWarrior(0).ThreadCount())
+}
+
+func TestInRange(t *testing.T) {
+	require.True(t, inRange(5, 5, 5))
+	require.True(t, inRange(6, 5, 10))
+	require.False(t, inRange(11, 5, 10))
+	require.True(t, inRange(7999, 7990, 10))
+	require.True(t, inRange(3, 7990, 10))
+	require.False(t, inRange(20, 7990, 10))
+}
@@ -4,7 +4,8 @@
 // The Simulator interface provides a MARS implemenation for running
 // simulations, and the ReportingSimulator interface adds the Addreporter
 // method to inject Reporter interfaces to recieve callbacks to report state
-// changes in the simulation.
+// changes in the simulation. NewFastSimulator returns a Simulator without
+// reporting hooks, intended for running large numbers of rounds.
 //
 // RedCode files are first loaded as WarriorData{} structs, holding the data
 // needed to create a Warrior inside a Simulator. These can safely be reused
@@ -0,0 +1,544 @@
+package mars
+
+// fastOpFunc executes an instruction after its operands have been evaluated.
+// RAB is the address referenced by the A operand and WAB is the address
+// written by the B operand.
+type fastOpFunc func(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell)
+
+// fastOps is the fastSim dispatch table indexed by [OpCode][OpMode]
+var fastOps = [NOP + 1][I + 1]fastOpFunc{
+	DAT: {F: fastDat, A: fastDat, B: fastDat, AB: fastDat, BA: fastDat, X: fastDat, I: fastDat},
+	MOV: {F: fastMovF, A: fastMovA, B: fastMovB, AB: fastMovAB, BA: fastMovBA, X: fastMovX, I: fastMovI},
+	ADD: {F: fastAddF, A: fastAddA, B: fastAddB, AB: fastAddAB, BA: fastAddBA, X: fastAddX, I: fastAddF},
+	SUB: {F: fastSubF, A: fastSubA, B: fastSubB, AB: fastSubAB, BA: fastSubBA, X: fastSubX, I: fastSubF},
+	MUL: {F: fastMulF, A: fastMulA, B: fastMulB, AB: fastMulAB, BA: fastMulBA, X: fastMulX, I: fastMulF},
+	DIV: {F: fastDivF, A: fastDivA, B: fastDivB, AB: fastDivAB, BA: fastDivBA, X: fastDivX, I: fastDivF},
+	MOD: {F: fastModF, A: fastModA, B: fastModB, AB: fastModAB, BA: fastModBA, X: fastModX, I: fastModF},
+	CMP: {F: fastSeqF, A: fastSeqA, B: fastSeqB, AB: fastSeqAB, BA: fastSeqBA, X: fastSeqX, I: fastSeqI},
+	SEQ: {F: fastSeqF, A: fastSeqA, B: fastSeqB, AB: fastSeqAB, BA: fastSeqBA, X: fastSeqX, I: fastSeqI},
+	SNE: {F: fastSneF, A: fastSneA, B: fastSneB, AB: fastSneAB, BA: fastSneBA, X: fastSneX, I: fastSneI},
+	SLT: {F: fastSltF, A: fastSltA, B: fastSltB, AB: fastSltAB, BA: fastSltBA, X: fastSltX, I: fastSltF},
+	JMP: {F: fastJmp, A: fastJmp, B: fastJmp, AB: fastJmp, BA: fastJmp, X: fastJmp, I: fastJmp},
+	JMZ: {F: fastJmzF, A: fastJmzA, B: fastJmzB, AB: fastJmzB, BA: fastJmzA, X: fastJmzF, I: fastJmzF},
+	JMN: {F: fastJmnF, A: fastJmnA, B: fastJmnB, AB: fastJmnB, BA: fastJmnA, X: fastJmnF, I: fastJmnF},
+	DJN: {F: fastDjnF, A: fastDjnA, B: fastDjnB, AB: fastDjnB, BA: fastDjnA, X: fastDjnF, I: fastDjnF},
+	SPL: {F: fastSpl, A: fastSpl, B: fastSpl, AB: fastSpl, BA: fastSpl, X: fastSpl, I: fastSpl},
+	NOP: {F: fastNop, A: fastNop, B: fastNop, AB: fastNop, BA: fastNop, X: fastNop, I: fastNop},
+}
+
+// fastInvalidOpMode executes a standard op code with an out of range
+// modifier like reportSim, where no modifier case matches: nothing is
+// written, and only ops that queue a process outside of the modifier cases
+// continue the task.
+func fastInvalidOpMode(s *fastSim, w *warrior, PC, RAB Address, op OpCode) {
+	switch op {
+	case MOV, ADD, SUB, MUL, DIV, MOD, NOP:
+		w.pq.Push(s.addm(PC, 1))
+	case JMP:
+		w.pq.Push(RAB)
+	case SPL:
+		w.pq.Push(s.addm(PC, 1))
+		w.pq.Push(RAB)
+	}
+}
+
+func fastDat(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+}
+
+func fastMovA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = IRA.A
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMovB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = IRA.B
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMovAB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = IRA.A
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMovBA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = IRA.B
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMovF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = IRA.A
+	s.mem[WAB].B = IRA.B
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMovX(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = IRA.A
+	s.mem[WAB].A = IRA.B
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMovI(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB] = IRA
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastAddA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(IRB.A, IRA.A)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastAddB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = s.addm32(IRB.B, IRA.B)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastAddAB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = s.addm32(IRB.B, IRA.A)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastAddBA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(IRB.A, IRA.B)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastAddF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(IRB.A, IRA.A)
+	s.mem[WAB].B = s.addm32(IRB.B, IRA.B)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastAddX(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(IRB.A, IRA.B)
+	s.mem[WAB].B = s.addm32(IRB.B, IRA.A)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastSubA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(IRB.A, s.m32-IRA.A)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastSubB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = s.addm32(IRB.B, s.m32-IRA.B)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastSubAB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = s.addm32(IRB.B, s.m32-IRA.A)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastSubBA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(IRB.A, s.m32-IRA.B)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastSubF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(IRB.A, s.m32-IRA.A)
+	s.mem[WAB].B = s.addm32(IRB.B, s.m32-IRA.B)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastSubX(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(IRB.A, s.m32-IRA.B)
+	s.mem[WAB].B = s.addm32(IRB.B, s.m32-IRA.A)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMulA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.mulm(IRB.A, IRA.A)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMulB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = s.mulm(IRB.B, IRA.B)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMulAB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = s.mulm(IRB.B, IRA.A)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMulBA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.mulm(IRB.A, IRA.B)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMulF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.mulm(IRB.A, IRA.A)
+	s.mem[WAB].B = s.mulm(IRB.B, IRA.B)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastMulX(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.mulm(IRB.A, IRA.B)
+	s.mem[WAB].B = s.mulm(IRB.B, IRA.A)
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastDivA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A == 0 {
+		return
+	}
+	s.mem[WAB].A = IRB.A / IRA.A
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastDivB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B == 0 {
+		return
+	}
+	s.mem[WAB].B = IRB.B / IRA.B
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastDivAB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A == 0 {
+		return
+	}
+	s.mem[WAB].B = IRB.B / IRA.A
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastDivBA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B == 0 {
+		return
+	}
+	s.mem[WAB].A = IRB.A / IRA.B
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastDivF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A != 0 {
+		s.mem[WAB].A = IRB.A / IRA.A
+	}
+	if IRA.B != 0 {
+		s.mem[WAB].B = IRB.B / IRA.B
+	}
+	if IRA.A == 0 || IRA.B == 0 {
+		return
+	}
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastDivX(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B != 0 {
+		s.mem[WAB].A = IRB.A / IRA.B
+	}
+	if IRA.A != 0 {
+		s.mem[WAB].B = IRB.B / IRA.A
+	}
+	if IRA.A == 0 || IRA.B == 0 {
+		return
+	}
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastModA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A == 0 {
+		return
+	}
+	s.mem[WAB].A = IRB.A % IRA.A
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastModB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B == 0 {
+		return
+	}
+	s.mem[WAB].B = IRB.B % IRA.B
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastModAB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A == 0 {
+		return
+	}
+	s.mem[WAB].B = IRB.B % IRA.A
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastModBA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B == 0 {
+		return
+	}
+	s.mem[WAB].A = IRB.A % IRA.B
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastModF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A != 0 {
+		s.mem[WAB].A = IRB.A % IRA.A
+	}
+	if IRA.B != 0 {
+		s.mem[WAB].B = IRB.B % IRA.B
+	}
+	if IRA.A == 0 || IRA.B == 0 {
+		return
+	}
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastModX(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B != 0 {
+		s.mem[WAB].A = IRB.A % IRA.B
+	}
+	if IRA.A != 0 {
+		s.mem[WAB].B = IRB.B % IRA.A
+	}
+	if IRA.A == 0 || IRA.B == 0 {
+		return
+	}
+	w.pq.Push(s.addm(PC, 1))
+}
+
+func fastSeqA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A == IRB.A {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSeqB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B == IRB.B {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSeqAB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A == IRB.B {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSeqBA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B == IRB.A {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSeqF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A == IRB.A && IRA.B == IRB.B {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSeqX(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A == IRB.B && IRA.B == IRB.A {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSneA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A != IRB.A {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSneB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B != IRB.B {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSneAB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A != IRB.B {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSneBA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B != IRB.A {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSneF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A != IRB.A || IRA.B != IRB.B {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSneX(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A != IRB.B || IRA.B != IRB.A {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSeqI(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA == IRB {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSneI(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA != IRB {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSltA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A < IRB.A {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSltB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B < IRB.B {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSltAB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A < IRB.B {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSltBA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.B < IRB.A {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSltF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A < IRB.A && IRA.B < IRB.B {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSltX(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRA.A < IRB.B && IRA.B < IRB.A {
+		w.pq.Push(s.addm(PC, 2))
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastJmp(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	w.pq.Push(RAB)
+}
+
+func fastJmzA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRB.A == 0 {
+		w.pq.Push(RAB)
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastJmzB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRB.B == 0 {
+		w.pq.Push(RAB)
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastJmzF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRB.A == 0 && IRB.B == 0 {
+		w.pq.Push(RAB)
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastJmnA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRB.A != 0 {
+		w.pq.Push(RAB)
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastJmnB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRB.B != 0 {
+		w.pq.Push(RAB)
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastJmnF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	if IRB.A != 0 || IRB.B != 0 {
+		w.pq.Push(RAB)
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastDjnA(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(s.mem[WAB].A, s.m32-1)
+	if IRB.A != 1 {
+		w.pq.Push(RAB)
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastDjnB(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].B = s.addm32(s.mem[WAB].B, s.m32-1)
+	if IRB.B != 1 {
+		w.pq.Push(RAB)
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastDjnF(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	s.mem[WAB].A = s.addm32(s.mem[WAB].A, s.m32-1)
+	s.mem[WAB].B = s.addm32(s.mem[WAB].B, s.m32-1)
+	if IRB.A != 1 || IRB.B != 1 {
+		w.pq.Push(RAB)
+	} else {
+		w.pq.Push(s.addm(PC, 1))
+	}
+}
+
+func fastSpl(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	w.pq.Push(s.addm(PC, 1))
+	w.pq.Push(RAB)
+}
+
+func fastNop(s *fastSim, w *warrior, PC, RAB, WAB Address, IRA, IRB cell) {
+	w.pq.Push(s.addm(PC, 1))
+}
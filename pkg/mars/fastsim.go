@@ -0,0 +1,235 @@
+package mars
+
+// fastSim is a Simulator without reporting hooks. Operands are evaluated
+// with a single switch on each addressing mode and instructions are
+// dispatched through the fastOps table instead of nested switches. Results
+// are identical to reportSim.
+type fastSim struct {
+	simState
+}
+
+// NewFastSimulator returns a Simulator optimized for running large numbers
+// of rounds. It does not support reporting.
+func NewFastSimulator(config SimulatorConfig) (Simulator, error) {
+	return newFastSim(config)
+}
+
+func newFastSim(config SimulatorConfig) (*fastSim, error) {
+	state, err := newSimState(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fastSim{simState: state}, nil
+}
+
+func (s *fastSim) SpawnWarrior(wi int, startOffset Address) error {
+	_, err := s.spawnWarrior(wi, startOffset)
+	return err
+}
+
+// RunCycle finds the next living warrior, returns 0 if none are found, or
+// executes a cycle and returns the number of living warriors at the end
+// of the cycle
+func (s *fastSim) RunCycle() int {
+	if s.journal != nil {
+		s.journalBegin()
+	}
+
+	warrior, pc, ok := s.popTask()
+	if !ok {
+		return 0
+	}
+
+	if s.journal != nil {
+		s.journalPop(warrior)
+	}
+
+	IR := s.mem[pc]
+	s.exec(pc, warrior)
+	s.endTask(warrior, IR)
+
+	if s.journal != nil {
+		s.journalCommit()
+	}
+
+	return s.endCycle()
+}
+
+// Run runs the simulator until the max cycles are reached, one warrior
+// remains in a battle with more than one warrior, or the only warrior
+// dies in a single warrior battle
+func (s *fastSim) Run() BattleResult {
+	return s.run(s.RunCycle)
+}
+
+func (s *fastSim) Restore(snap *Snapshot) error {
+	return s.restore(snap)
+}
+
+func (s *fastSim) Clone() Simulator {
+	c := &fastSim{}
+	s.cloneInto(&c.simState)
+	return c
+}
+
+func (s *fastSim) RunRound(offsets []Address, r *BattleResult) error {
+	return s.runRound(s.Reset, s.SpawnWarrior, s.RunCycle, offsets, r)
+}
+
+func (s *fastSim) Reset() {
+	s.reset()
+}
+
+// addm returns (a + b) % s.m for a, b < s.m without a division
+func (s *fastSim) addm(a, b Address) Address {
+	r := a + b
+	if r >= s.m {
+		r -= s.m
+	}
+	return r
+}
+
+// addm32 returns (a + b) % s.m for core fields a, b < s.m without a
+// division
+func (s *fastSim) addm32(a, b uint32) uint32 {
+	r := a + b
+	if r >= s.m32 {
+		r -= s.m32
+	}
+	return r
+}
+
+// rfold folds a pointer less than 2*s.m to the read limit
+func (s *fastSim) rfold(p Address) Address {
+	if s.readLimit == s.m {
+		if p >= s.m {
+			p -= s.m
+		}
+		return p
+	}
+	return s.readFold(p)
+}
+
+// wfold folds a pointer less than 2*s.m to the write limit
+func (s *fastSim) wfold(p Address) Address {
+	if s.writeLimit == s.m {
+		if p >= s.m {
+			p -= s.m
+		}
+		return p
+	}
+	return s.writeFold(p)
+}
+
+func (s *fastSim) exec(PC Address, w *warrior) {
+	m32 := s.m32
+	mem := s.mem
+	IR := mem[PC]
+
+	if s.journal != nil {
+		s.journalOperands(PC, IR)
+	}
+
+	// read and write limit folded pointers for A, B
+	var RPA, WPA, RPB, WPB Address
+
+	// pointer to increment after IRA, IRB
+	var PIP Address
+
+	// invalid modes are treated as direct, as in reportSim
+	switch IR.AMode {
+	case IMMEDIATE:
+	default:
+		RPA = s.rfold(Address(IR.A))
+	case A_INDIRECT, A_DECREMENT, A_INCREMENT:
+		RPA = s.rfold(Address(IR.A))
+		WPA = s.wfold(Address(IR.A))
+		if IR.AMode == A_DECREMENT {
+			dptr := s.addm(PC, WPA)
+			mem[dptr].A = s.addm32(mem[dptr].A, m32-1)
+		} else if IR.AMode == A_INCREMENT {
+			PIP = s.addm(PC, WPA)
+		}
+		RPA = s.rfold(RPA + Address(mem[s.addm(PC, RPA)].A))
+	case B_INDIRECT, B_DECREMENT, B_INCREMENT:
+		RPA = s.rfold(Address(IR.A))
+		WPA = s.wfold(Address(IR.A))
+		if IR.AMode == B_DECREMENT {
+			dptr := s.addm(PC, WPA)
+			mem[dptr].B = s.addm32(mem[dptr].B, m32-1)
+		} else if IR.AMode == B_INCREMENT {
+			PIP = s.addm(PC, WPA)
+		}
+		RPA = s.rfold(RPA + Address(mem[s.addm(PC, RPA)].B))
+	}
+
+	RAB := s.addm(PC, RPA)
+	IRA := mem[RAB]
+
+	if IR.AMode == A_INCREMENT {
+		mem[PIP].A = s.addm32(mem[PIP].A, 1)
+	} else if IR.AMode == B_INCREMENT {
+		mem[PIP].B = s.addm32(mem[PIP].B, 1)
+	}
+
+	switch IR.BMode {
+	case IMMEDIATE:
+	default:
+		RPB = s.rfold(Address(IR.B))
+		WPB = s.wfold(Address(IR.B))
+	case A_INDIRECT, A_DECREMENT, A_INCREMENT:
+		RPB = s.rfold(Address(IR.B))
+		WPB = s.wfold(Address(IR.B))
+		if IR.BMode == A_DECREMENT {
+			dptr := s.addm(PC, WPB)
+			mem[dptr].A = s.addm32(mem[dptr].A, m32-1)
+		} else if IR.BMode == A_INCREMENT {
+			PIP = s.addm(PC, WPB)
+		}
+		RPB = s.rfold(RPB + Address(mem[s.addm(PC, RPB)].A))
+		WPB = s.wfold(WPB + Address(mem[s.addm(PC, WPB)].A))
+	case B_INDIRECT, B_DECREMENT, B_INCREMENT:
+		RPB = s.rfold(Address(IR.B))
+		WPB = s.wfold(Address(IR.B))
+		if IR.BMode == B_DECREMENT {
+			dptr := s.addm(PC, WPB)
+			mem[dptr].B = s.addm32(mem[dptr].B, m32-1)
+		} else if IR.BMode == B_INCREMENT {
+			PIP = s.addm(PC, WPB)
+		}
+		RPB = s.rfold(RPB + Address(mem[s.addm(PC, RPB)].B))
+		WPB = s.wfold(WPB + Address(mem[s.addm(PC, WPB)].B))
+	}
+
+	IRB := mem[s.addm(PC, RPB)]
+
+	if IR.BMode == A_INCREMENT {
+		mem[PIP].A = s.addm32(mem[PIP].A, 1)
+	} else if IR.BMode == B_INCREMENT {
+		mem[PIP].B = s.addm32(mem[PIP].B, 1)
+	}
+
+	WAB := s.addm(PC, WPB)
+
+	// unknown op codes are run by extensions or terminate the task
+	if IR.Op > NOP {
+		if s.opcodes != nil {
+			if s.journal != nil {
+				s.journalCell(WAB)
+			}
+			s.execExtension(s, w, PC, RAB, WAB, IR, IRA, IRB)
+		}
+		return
+	}
+
+	if s.journal != nil {
+		s.journalCell(WAB)
+	}
+
+	if IR.OpMode > I {
+		fastInvalidOpMode(s, w, PC, RAB, IR.Op)
+		return
+	}
+	fastOps[IR.Op][IR.OpMode](s, w, PC, RAB, WAB, IRA, IRB)
+}
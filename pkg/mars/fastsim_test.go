@@ -0,0 +1,185 @@
+package mars
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomInstruction(r *rand.Rand, coresize Address) Instruction {
+	return Instruction{
+		Op:     OpCode(r.Intn(int(NOP) + 1)),
+		OpMode: OpMode(r.Intn(int(I) + 1)),
+		AMode:  AddressMode(r.Intn(int(B_INCREMENT) + 1)),
+		A:      Address(r.Intn(int(coresize))),
+		BMode:  AddressMode(r.Intn(int(B_INCREMENT) + 1)),
+		B:      Address(r.Intn(int(coresize))),
+	}
+}
+
+func randomWarrior(r *rand.Rand, length int, coresize Address) *WarriorData {
+	code := make([]Instruction, length)
+	for i := range code {
+		code[i] = randomInstruction(r, coresize)
+		// avoid warriors that die immediately
+		if code[i].Op == DAT {
+			code[i].Op = SPL
+		}
+	}
+	return &WarriorData{Code: code, Start: r.Intn(length)}
+}
+
+func requireSimsEqual(t *testing.T, a, b *simState, msg string) {
+	require.Equal(t, a.cycleCount, b.cycleCount, msg)
+	require.Equal(t, a.warriorIndex, b.warriorIndex, msg)
+	require.Equal(t, a.mem, b.mem, msg)
+	for i := range a.warriors {
+		require.Equal(t, a.warriors[i].state, b.warriors[i].state, msg)
+		require.Equal(t, a.warriors[i].pq.Values(), b.warriors[i].pq.Values(), msg)
+	}
+}
+
+// requireSimsAgree runs the warriors on both simulators and requires the
+// same state after every cycle
+func requireSimsAgree(t *testing.T, config SimulatorConfig, warriors []*WarriorData, msg string) {
+	rsim, err := newReportSim(config)
+	require.NoError(t, err)
+	fsim, err := newFastSim(config)
+	require.NoError(t, err)
+
+	for wi, data := range warriors {
+		offset := Address(wi * 100)
+		_, err = rsim.AddWarrior(data)
+		require.NoError(t, err)
+		require.NoError(t, rsim.SpawnWarrior(wi, offset))
+		_, err = fsim.AddWarrior(data)
+		require.NoError(t, err)
+		require.NoError(t, fsim.SpawnWarrior(wi, offset))
+	}
+
+	for i := 0; i < int(config.Cycles); i++ {
+		ra := rsim.RunCycle()
+		fa := fsim.RunCycle()
+		require.Equal(t, ra, fa)
+		requireSimsEqual(t, &rsim.simState, &fsim.simState, fmt.Sprintf("%s cycle %d", msg, i))
+		if ra < 2 {
+			break
+		}
+	}
+}
+
+func TestFastSimMatchesReportSim(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		config := NewQuickConfig(ICWS94, 200, 64, 2000, 20)
+		if seed%2 == 1 {
+			config.ReadLimit = 50
+			config.WriteLimit = 40
+		}
+		if seed%4 >= 2 {
+			config.Processes = 8
+			config.QueuePolicy = QueueDropOldest
+		}
+
+		warriors := []*WarriorData{randomWarrior(r, 20, config.CoreSize), randomWarrior(r, 20, config.CoreSize)}
+		requireSimsAgree(t, config, warriors, fmt.Sprintf("seed %d", seed))
+	}
+}
+
+func TestFastSimMatchesReportSimMalformed(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		config := NewQuickConfig(ICWS94, 200, 64, 2000, 20)
+
+		var warriors []*WarriorData
+		for wi := 0; wi < 2; wi++ {
+			data := randomWarrior(r, 20, config.CoreSize)
+			// make some modifiers and modes out of range
+			for i := range data.Code {
+				switch r.Intn(4) {
+				case 0:
+					data.Code[i].OpMode = I + 1 + OpMode(r.Intn(3))
+				case 1:
+					data.Code[i].AMode = B_INCREMENT + 1 + AddressMode(r.Intn(3))
+				case 2:
+					data.Code[i].BMode = B_INCREMENT + 1 + AddressMode(r.Intn(3))
+				}
+			}
+			warriors = append(warriors, data)
+		}
+		requireSimsAgree(t, config, warriors, fmt.Sprintf("seed %d", seed))
+	}
+}
+
+func TestFastSimDwarf(t *testing.T) {
+	config := ConfigNOP94()
+	data := makeDwarfData()
+
+	sim, err := NewFastSimulator(config)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(data)
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	for i := 0; i < 9; i++ {
+		sim.RunCycle()
+	}
+	require.Equal(t, Instruction{Op: DAT, OpMode: F, AMode: IMMEDIATE, BMode: IMMEDIATE, B: 12}, sim.GetMem(3))
+	require.Equal(t, Instruction{Op: DAT, OpMode: F, AMode: IMMEDIATE, BMode: IMMEDIATE, B: 4}, sim.GetMem(7))
+}
+
+func benchmarkSim(b *testing.B, newSim func(SimulatorConfig) (Simulator, error)) {
+	config := ConfigNOP94()
+	dwarf := makeDwarfData()
+	impdata, err := ParseLoadFile(strings.NewReader(imp94), config)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sim, err := newSim(config)
+		require.NoError(b, err)
+		_, err = sim.AddWarrior(dwarf)
+		require.NoError(b, err)
+		require.NoError(b, sim.SpawnWarrior(0, 0))
+		_, err = sim.AddWarrior(&impdata)
+		require.NoError(b, err)
+		require.NoError(b, sim.SpawnWarrior(1, 4000))
+		sim.Run()
+	}
+}
+
+func BenchmarkReportSim(b *testing.B) {
+	benchmarkSim(b, NewSimulator)
+}
+
+func BenchmarkFastSim(b *testing.B) {
+	benchmarkSim(b, NewFastSimulator)
+}
+
+// BenchmarkFastSimParallel runs independent rounds on every CPU, where the
+// size of each core affects cache use
+func BenchmarkFastSimParallel(b *testing.B) {
+	config := ConfigNOP94()
+	dwarf := makeDwarfData()
+	impdata, err := ParseLoadFile(strings.NewReader(imp94), config)
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sim, err := NewFastSimulator(config)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			sim.AddWarrior(dwarf)
+			sim.SpawnWarrior(0, 0)
+			sim.AddWarrior(&impdata)
+			sim.SpawnWarrior(1, 4000)
+			sim.Run()
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package mars
+
+// journalQueue holds the process queue indices of a warrior before a cycle
+type journalQueue struct {
+	state      WarriorState
+	deathCycle int
+	deathCause TerminateCause
+	peakProcs  Address
+	start      Address
+	end        Address
+	length     Address
+	dropped    Address
+}
+
+// journalCell holds the value of a core address before it was modified
+type journalCell struct {
+	address Address
+	value   cell
+}
+
+// journalSlot holds the value of a slot of the executing warrior's process
+// queue before it was overwritten
+type journalSlot struct {
+	index Address
+	value Address
+}
+
+// journalEntry holds the state needed to undo a single cycle. Standard
+// instructions push at most two processes, so the first two slots of the
+// executing warrior's queue are always recorded, and op code extensions
+// record each slot they push to. The queue indices and those slots are
+// enough to restore the queue. Standard instructions modify at most three
+// cells, and extensions record each cell they write. The slices keep their
+// capacity when entries are reused.
+type journalEntry struct {
+	cycle        Address
+	warriorIndex int
+	queues       []journalQueue
+	popWarrior   int
+	slots        []journalSlot
+	cells        []journalCell
+}
+
+// undoJournal is a ring buffer of the most recent journal entries
+type undoJournal struct {
+	entries []journalEntry
+	next    int
+	length  int
+}
+
+func newUndoJournal(depth int) *undoJournal {
+	return &undoJournal{entries: make([]journalEntry, depth)}
+}
+
+func (j *undoJournal) clear() {
+	j.next = 0
+	j.length = 0
+}
+
+// EnableUndo starts recording the changes made by each cycle so that up to
+// depth cycles can be undone with StepBack. A depth of 0 disables the
+// journal.
+func (s *simState) EnableUndo(depth int) {
+	if depth <= 0 {
+		s.journal = nil
+		return
+	}
+	s.journal = newUndoJournal(depth)
+}
+
+// journalBegin starts a new journal entry for the next cycle
+func (s *simState) journalBegin() {
+	j := s.journal
+	e := &j.entries[j.next]
+	e.cycle = s.cycleCount
+	e.warriorIndex = s.warriorIndex
+	e.popWarrior = -1
+	e.slots = e.slots[:0]
+	e.cells = e.cells[:0]
+
+	if cap(e.queues) < len(s.warriors) {
+		e.queues = make([]journalQueue, len(s.warriors))
+	}
+	e.queues = e.queues[:len(s.warriors)]
+	for i, w := range s.warriors {
+		e.queues[i] = journalQueue{state: w.state, deathCycle: w.deathCycle, deathCause: w.deathCause, peakProcs: w.peakProcs}
+		if w.pq != nil {
+			e.queues[i].start = w.pq.start
+			e.queues[i].end = w.pq.end
+			e.queues[i].length = w.pq.length
+			e.queues[i].dropped = w.pq.dropped
+		}
+	}
+}
+
+// journalPop records the queue slots of the warrior executing the cycle
+func (s *simState) journalPop(w *warrior) {
+	e := &s.journal.entries[s.journal.next]
+	e.popWarrior = w.index
+	start := e.queues[w.index].start
+	second := (start + 1) % w.pq.size
+	e.slots = append(e.slots,
+		journalSlot{index: start, value: w.pq.queue[start]},
+		journalSlot{index: second, value: w.pq.queue[second]})
+}
+
+// journalPush records the queue slot overwritten by the next push to the
+// executing warrior's queue
+func (s *simState) journalPush(w *warrior) {
+	e := &s.journal.entries[s.journal.next]
+	e.slots = append(e.slots, journalSlot{index: w.pq.end, value: w.pq.queue[w.pq.end]})
+}
+
+// journalCell records the value of a core address before it is modified
+func (s *simState) journalCell(a Address) {
+	e := &s.journal.entries[s.journal.next]
+	e.cells = append(e.cells, journalCell{address: a, value: s.mem[a]})
+}
+
+// journalOperands records the addresses that may be incremented or
+// decremented while evaluating the operands of IR
+func (s *simState) journalOperands(PC Address, IR cell) {
+	if IR.AMode >= A_DECREMENT {
+		s.journalCell((PC + s.writeFold(Address(IR.A))) % s.m)
+	}
+	if IR.BMode >= A_DECREMENT {
+		s.journalCell((PC + s.writeFold(Address(IR.B))) % s.m)
+	}
+}
+
+// journalCommit adds the current entry to the journal
+func (s *simState) journalCommit() {
+	j := s.journal
+	j.next = (j.next + 1) % len(j.entries)
+	if j.length < len(j.entries) {
+		j.length++
+	}
+}
+
+// StepBack undoes up to n cycles recorded in the journal and returns the
+// number of cycles undone
+func (s *simState) StepBack(n int) int {
+	if s.journal == nil {
+		return 0
+	}
+	j := s.journal
+
+	undone := 0
+	for ; undone < n && j.length > 0; undone++ {
+		j.next = (j.next + len(j.entries) - 1) % len(j.entries)
+		j.length--
+		e := &j.entries[j.next]
+
+		for i := len(e.cells) - 1; i >= 0; i-- {
+			s.mem[e.cells[i].address] = e.cells[i].value
+		}
+
+		for i, q := range e.queues {
+			w := s.warriors[i]
+			w.state = q.state
+			w.deathCycle = q.deathCycle
+			w.deathCause = q.deathCause
+			w.peakProcs = q.peakProcs
+			if w.pq != nil {
+				w.pq.start = q.start
+				w.pq.end = q.end
+				w.pq.length = q.length
+				w.pq.dropped = q.dropped
+			}
+		}
+		if e.popWarrior >= 0 {
+			pq := s.warriors[e.popWarrior].pq
+			for i := len(e.slots) - 1; i >= 0; i-- {
+				pq.queue[e.slots[i].index] = e.slots[i].value
+			}
+		}
+
+		s.cycleCount = e.cycle
+		s.warriorIndex = e.warriorIndex
+	}
+
+	return undone
+}
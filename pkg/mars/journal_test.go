@@ -0,0 +1,109 @@
+package mars
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepBack(t *testing.T) {
+	for _, newSim := range []func(SimulatorConfig) (Simulator, error){NewSimulator, NewFastSimulator} {
+		for seed := int64(0); seed < 20; seed++ {
+			r := rand.New(rand.NewSource(seed))
+			config := NewQuickConfig(ICWS94, 200, 8, 2000, 20)
+			if seed%2 == 1 {
+				config.ReadLimit = 50
+				config.WriteLimit = 40
+			}
+			if seed%4 >= 2 {
+				config.QueuePolicy = QueueDropOldest
+			}
+
+			sim, err := newSim(config)
+			require.NoError(t, err)
+			for wi := 0; wi < 2; wi++ {
+				_, err = sim.AddWarrior(randomWarrior(r, 20, config.CoreSize))
+				require.NoError(t, err)
+				require.NoError(t, sim.SpawnWarrior(wi, Address(wi*100)))
+			}
+			sim.EnableUndo(100)
+
+			snaps := []*Snapshot{sim.Snapshot()}
+			for i := 0; i < 100; i++ {
+				if sim.RunCycle() < 2 {
+					break
+				}
+				snaps = append(snaps, sim.Snapshot())
+			}
+			// the final cycle may have ended the battle
+			snaps = append(snaps, sim.Snapshot())
+			if snaps[len(snaps)-1].Cycle == snaps[len(snaps)-2].Cycle {
+				snaps = snaps[:len(snaps)-1]
+			}
+
+			for i := len(snaps) - 2; i >= 0; i-- {
+				require.Equal(t, 1, sim.StepBack(1))
+				require.Equal(t, snaps[i], sim.Snapshot(), fmt.Sprintf("seed %d cycle %d", seed, i))
+			}
+			require.Equal(t, 0, sim.StepBack(1))
+		}
+	}
+}
+
+func TestStepBackDepth(t *testing.T) {
+	sim := newSplitImpBattle(t, NewFastSimulator, 1)
+	sim.EnableUndo(10)
+
+	for i := 0; i < 20; i++ {
+		sim.RunCycle()
+	}
+	snap := sim.Snapshot()
+	for i := 0; i < 5; i++ {
+		sim.RunCycle()
+	}
+
+	require.Equal(t, 5, sim.StepBack(5))
+	require.Equal(t, snap, sim.Snapshot())
+	require.Equal(t, 5, sim.StepBack(20))
+	require.Equal(t, 15, sim.CycleCount())
+}
+
+func TestStepBackDisabled(t *testing.T) {
+	sim := newSplitImpBattle(t, NewSimulator, 1)
+	sim.RunCycle()
+	require.Equal(t, 0, sim.StepBack(1))
+	require.Equal(t, 1, sim.CycleCount())
+}
+
+func TestStepBackExtension(t *testing.T) {
+	for _, newSim := range []func(SimulatorConfig) (Simulator, error){NewSimulator, NewFastSimulator} {
+		for _, policy := range []QueuePolicy{QueueDropNew, QueueDropOldest} {
+			config := testOpcodeConfig(t)
+			config.Processes = 4
+			config.QueuePolicy = policy
+
+			sim, err := newSim(config)
+			require.NoError(t, err)
+			_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{
+				{Op: SPL, OpMode: B, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+				{Op: SPR, OpMode: I, AMode: DIRECT, A: 0, BMode: B_DECREMENT, B: 10},
+				{Op: JMP, OpMode: B, AMode: DIRECT, A: -2 + 8000, BMode: DIRECT, B: 0},
+			}})
+			require.NoError(t, err)
+			require.NoError(t, sim.SpawnWarrior(0, 0))
+			sim.EnableUndo(50)
+
+			snaps := []*Snapshot{sim.Snapshot()}
+			for i := 0; i < 30; i++ {
+				sim.RunCycle()
+				snaps = append(snaps, sim.Snapshot())
+			}
+			for i := len(snaps) - 2; i >= 0; i-- {
+				require.Equal(t, 1, sim.StepBack(1))
+				require.Equal(t, snaps[i], sim.Snapshot(), fmt.Sprintf("policy %s cycle %d", policy, i))
+			}
+		}
+	}
+}
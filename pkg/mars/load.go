@@ -8,7 +8,7 @@ import (
 	"strings"
 )
 
-func parseLoadFile94(reader io.Reader, coresize Address) (WarriorData, error) {
+func parseLoadFile94(reader io.Reader, coresize Address, opcodes opcodeSet) (WarriorData, error) {
 	data := WarriorData{
 		Name:     "Unknown",
 		Author:   "Anonymous",
@@ -93,7 +93,7 @@ func parseLoadFile94(reader io.Reader, coresize Address) (WarriorData, error) {
 			return WarriorData{}, fmt.Errorf("line %d: missing comma", lineNum)
 		}
 
-		op, opmode, err := getOp94(fields[0])
+		op, opmode, err := getOp94(fields[0], opcodes)
 		if err != nil {
 			return WarriorData{}, fmt.Errorf("line %d: %s", lineNum, err)
 		}
@@ -116,6 +116,12 @@ func parseLoadFile94(reader io.Reader, coresize Address) (WarriorData, error) {
 			return WarriorData{}, fmt.Errorf("line %d: error parsing b field integer: %s", lineNum, err)
 		}
 
+		if h, ok := opcodes[op]; ok {
+			if err := h.Validate(opmode, amode, bmode); err != nil {
+				return WarriorData{}, fmt.Errorf("line %d: %s", lineNum, err)
+			}
+		}
+
 		data.Code = append(data.Code, Instruction{
 			Op:     op,
 			OpMode: opmode,
@@ -342,5 +348,9 @@ func ParseLoadFile(reader io.Reader, simConfig SimulatorConfig) (WarriorData, er
 	if simConfig.Mode == ICWS88 {
 		return parseLoadFile88(reader, simConfig.CoreSize)
 	}
-	return parseLoadFile94(reader, simConfig.CoreSize)
+	opcodes, err := newOpcodeSet(simConfig.Opcodes)
+	if err != nil {
+		return WarriorData{}, err
+	}
+	return parseLoadFile94(reader, simConfig.CoreSize, opcodes)
 }
@@ -0,0 +1,365 @@
+package mars
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// maxPlacementAttempts is the number of random placements tried for each
+// round before giving up on a multi-warrior match
+const maxPlacementAttempts = 1000
+
+// Match describes a number of rounds played between the same warriors
+type Match struct {
+	Config   SimulatorConfig
+	Warriors []*WarriorData
+
+	// Rounds is the number of rounds played, unless Permute is set
+	Rounds int
+
+	// Seed selects the random starting positions. The positions of each
+	// round depend only on the seed and the round index.
+	Seed int64
+
+	// Fixed is the offset of the second warrior in every round of a two
+	// warrior match, or 0 to place it randomly
+	Fixed Address
+
+	// Permute plays the second warrior of a two warrior match once at every
+	// offset from Config.Distance to CoreSize-Distance, stepping by Stride,
+	// instead of Rounds random positions. Results for each offset are
+	// stored in MatchResult.Positions.
+	Permute bool
+	Stride  Address
+
+	// RecordPositions stores the result of each round in
+	// MatchResult.Positions for matches that do not permute
+	RecordPositions bool
+
+	// Workers is the number of simulators run in parallel, or 0 to use
+	// GOMAXPROCS
+	Workers int
+}
+
+// MatchResult holds the totals of a match for each warrior
+type MatchResult struct {
+	Rounds int   `json:"rounds"`
+	Wins   []int `json:"wins"`
+	Ties   []int `json:"ties"`
+	Losses []int `json:"losses"`
+
+	// Survived[i][k-1] counts the rounds warrior i ended alive with k
+	// warriors surviving, as used by score formulas
+	Survived [][]int `json:"survived"`
+
+	// Positions holds the result of each round of a permutation match, or
+	// of a match with RecordPositions set, ordered by round
+	Positions []PositionResult `json:"positions,omitempty"`
+}
+
+// PositionResult is the outcome of a round of a two warrior match with the
+// second warrior at Offset
+type PositionResult struct {
+	Offset Address `json:"offset"`
+	Winner int     `json:"winner"`
+	Tie    bool    `json:"tie"`
+	Cycles int     `json:"cycles"`
+}
+
+// NewMatchResult returns an empty result for n warriors
+func NewMatchResult(n int) MatchResult {
+	r := MatchResult{
+		Wins:     make([]int, n),
+		Ties:     make([]int, n),
+		Losses:   make([]int, n),
+		Survived: make([][]int, n),
+	}
+	for i := range r.Survived {
+		r.Survived[i] = make([]int, n)
+	}
+	return r
+}
+
+// Add counts the outcome of a round
+func (r *MatchResult) Add(br *BattleResult) {
+	r.Rounds++
+	survivors := 0
+	for _, w := range br.Warriors {
+		if w.Alive {
+			survivors++
+		}
+	}
+	for i, w := range br.Warriors {
+		if w.Alive {
+			r.Survived[i][survivors-1]++
+		}
+		switch {
+		case br.Winner == i:
+			r.Wins[i]++
+		case w.Alive:
+			r.Ties[i]++
+		default:
+			r.Losses[i]++
+		}
+	}
+}
+
+// merge adds the totals of another result
+func (r *MatchResult) merge(o MatchResult) {
+	r.Rounds += o.Rounds
+	for i := range r.Wins {
+		r.Wins[i] += o.Wins[i]
+		r.Ties[i] += o.Ties[i]
+		r.Losses[i] += o.Losses[i]
+		for k := range r.Survived[i] {
+			r.Survived[i][k] += o.Survived[i][k]
+		}
+	}
+}
+
+// splitmix64 is a small allocation free generator used to derive the
+// positions of a round from the match seed
+type splitmix64 uint64
+
+func (x *splitmix64) next() uint64 {
+	*x += 0x9e3779b97f4a7c15
+	z := uint64(*x)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// intn returns a value in [0, n)
+func (x *splitmix64) intn(n Address) Address {
+	return Address(x.next() % uint64(n))
+}
+
+func (m *Match) validate() error {
+	if len(m.Warriors) == 0 {
+		return fmt.Errorf("no warriors in match")
+	}
+	if m.Rounds < 0 {
+		return fmt.Errorf("invalid round count %d", m.Rounds)
+	}
+	if m.Workers < 0 {
+		return fmt.Errorf("invalid worker count %d", m.Workers)
+	}
+	for i, data := range m.Warriors {
+		if Address(len(data.Code)) > m.Config.Length {
+			return fmt.Errorf("warrior %d is longer than %d instructions", i+1, m.Config.Length)
+		}
+	}
+	if m.Permute {
+		if len(m.Warriors) != 2 {
+			return fmt.Errorf("permutation requires two warriors")
+		}
+		if m.Fixed != 0 {
+			return fmt.Errorf("permutation and fixed position are exclusive")
+		}
+	}
+	if m.Fixed != 0 {
+		if len(m.Warriors) != 2 {
+			return fmt.Errorf("fixed position requires two warriors")
+		}
+		if m.Fixed >= m.Config.CoreSize {
+			return fmt.Errorf("fixed position %d outside of core", m.Fixed)
+		}
+	}
+	if len(m.Warriors) > 1 && m.Config.CoreSize < 2*m.Config.Distance {
+		return fmt.Errorf("core too small for warrior distance %d", m.Config.Distance)
+	}
+	return m.Config.Validate()
+}
+
+// stride returns the step between offsets of a permutation match
+func (m *Match) stride() Address {
+	if m.Stride == 0 {
+		return 1
+	}
+	return m.Stride
+}
+
+// RoundCount returns the number of rounds played by a match, which is the
+// number of offsets of a permutation match
+func (m *Match) RoundCount() int {
+	if !m.Permute {
+		return m.Rounds
+	}
+	dist := m.Config.Distance
+	if m.Config.CoreSize < 2*dist {
+		return 0
+	}
+	return int((m.Config.CoreSize-2*dist)/m.stride()) + 1
+}
+
+// RoundOffsets stores the starting offsets of each warrior in a round in
+// offsets. The first warrior always starts at 0, and the others are placed
+// at least Config.Distance apart from each other.
+func (m *Match) RoundOffsets(round int, offsets []Address) error {
+	if len(offsets) != len(m.Warriors) {
+		return fmt.Errorf("expected %d offsets, got %d", len(m.Warriors), len(offsets))
+	}
+	if len(offsets) == 0 {
+		return nil
+	}
+	offsets[0] = 0
+	if len(offsets) == 1 {
+		return nil
+	}
+
+	if m.Fixed != 0 {
+		offsets[1] = m.Fixed
+		return nil
+	}
+	if m.Permute {
+		if round >= m.RoundCount() {
+			return fmt.Errorf("round %d out of range", round)
+		}
+		offsets[1] = m.Config.Distance + Address(round)*m.stride()
+		return nil
+	}
+
+	rng := splitmix64(uint64(m.Seed) ^ uint64(round)*0xd1b54a32d192ed03)
+
+	// warrior 2 may start anywhere from Distance to CoreSize-Distance
+	dist := m.Config.Distance
+	span := m.Config.CoreSize - 2*dist + 1
+	if len(offsets) == 2 {
+		offsets[1] = dist + rng.intn(span)
+		return nil
+	}
+
+	for attempt := 0; attempt < maxPlacementAttempts; attempt++ {
+		ok := true
+		for i := 1; i < len(offsets) && ok; i++ {
+			offsets[i] = dist + rng.intn(span)
+			for j := 1; j < i; j++ {
+				sep := (offsets[i] + m.Config.CoreSize - offsets[j]) % m.Config.CoreSize
+				if sep < dist || m.Config.CoreSize-sep < dist {
+					ok = false
+					break
+				}
+			}
+		}
+		if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("unable to place %d warriors in round %d", len(offsets), round)
+}
+
+// RunMatch plays the rounds of a match across a pool of fast simulators.
+// The totals do not depend on the number of workers.
+func RunMatch(ctx context.Context, m Match) (MatchResult, error) {
+	if err := m.validate(); err != nil {
+		return MatchResult{}, err
+	}
+
+	nRounds := m.RoundCount()
+	workers := m.Workers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > nRounds {
+		workers = nRounds
+	}
+
+	// workers store permutation results at the index of each round
+	var positions []PositionResult
+	if m.Permute || m.RecordPositions {
+		if len(m.Warriors) != 2 {
+			return MatchResult{}, fmt.Errorf("positions require two warriors")
+		}
+		positions = make([]PositionResult, nRounds)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rounds := make(chan int)
+	go func() {
+		defer close(rounds)
+		for i := 0; i < nRounds; i++ {
+			select {
+			case rounds <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	total := NewMatchResult(len(m.Warriors))
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := m.runWorker(ctx, rounds, positions)
+			if err != nil {
+				fail(err)
+				return
+			}
+			mu.Lock()
+			total.merge(result)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return MatchResult{}, firstErr
+	}
+	if err := ctx.Err(); err != nil && total.Rounds < nRounds {
+		return MatchResult{}, err
+	}
+	total.Positions = positions
+	return total, nil
+}
+
+// runWorker plays rounds received on a channel with a single simulator,
+// storing the result of each round in positions if it is not nil
+func (m *Match) runWorker(ctx context.Context, rounds <-chan int, positions []PositionResult) (MatchResult, error) {
+	result := NewMatchResult(len(m.Warriors))
+
+	sim, err := NewFastSimulator(m.Config)
+	if err != nil {
+		return result, err
+	}
+	for i, data := range m.Warriors {
+		if _, err := sim.AddWarrior(data); err != nil {
+			return result, fmt.Errorf("error adding warrior %d: %s", i+1, err)
+		}
+	}
+
+	offsets := make([]Address, len(m.Warriors))
+	br := BattleResult{}
+	for round := range rounds {
+		if err := m.RoundOffsets(round, offsets); err != nil {
+			return result, err
+		}
+		if err := sim.RunRound(offsets, &br); err != nil {
+			return result, err
+		}
+		result.Add(&br)
+		if positions != nil {
+			positions[round] = PositionResult{Offset: offsets[1], Winner: br.Winner, Tie: br.Tie, Cycles: br.Cycles}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return result, nil
+}
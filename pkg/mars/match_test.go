@@ -0,0 +1,197 @@
+package mars
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMatch(t *testing.T) Match {
+	config := ConfigNOP94()
+	impdata, err := ParseLoadFile(strings.NewReader(imp94), config)
+	require.NoError(t, err)
+
+	return Match{
+		Config:   config,
+		Warriors: []*WarriorData{makeDwarfData(), &impdata},
+		Rounds:   40,
+		Seed:     1234,
+	}
+}
+
+func TestRunMatchDeterministic(t *testing.T) {
+	m := newTestMatch(t)
+
+	m.Workers = 1
+	serial, err := RunMatch(context.Background(), m)
+	require.NoError(t, err)
+	require.Equal(t, 40, serial.Rounds)
+	for i := range m.Warriors {
+		require.Equal(t, 40, serial.Wins[i]+serial.Ties[i]+serial.Losses[i])
+		require.Equal(t, []int{serial.Wins[i], serial.Ties[i]}, serial.Survived[i])
+	}
+
+	for _, workers := range []int{0, 3, 8, 100} {
+		m.Workers = workers
+		parallel, err := RunMatch(context.Background(), m)
+		require.NoError(t, err)
+		require.Equal(t, serial, parallel)
+	}
+}
+
+func TestRunMatchMatchesRunRound(t *testing.T) {
+	m := newTestMatch(t)
+	m.Rounds = 10
+	m.Workers = 4
+	result, err := RunMatch(context.Background(), m)
+	require.NoError(t, err)
+
+	sim := newRoundSim(t, NewSimulator)
+	expected := NewMatchResult(2)
+	offsets := make([]Address, 2)
+	br := BattleResult{}
+	for round := 0; round < m.Rounds; round++ {
+		require.NoError(t, m.RoundOffsets(round, offsets))
+		require.NoError(t, sim.RunRound(offsets, &br))
+		expected.Add(&br)
+	}
+	require.Equal(t, expected, result)
+}
+
+func TestRoundOffsets(t *testing.T) {
+	m := newTestMatch(t)
+	offsets := make([]Address, 2)
+	seen := map[Address]bool{}
+	for round := 0; round < 1000; round++ {
+		require.NoError(t, m.RoundOffsets(round, offsets))
+		require.Equal(t, Address(0), offsets[0])
+		require.GreaterOrEqual(t, offsets[1], m.Config.Distance)
+		require.LessOrEqual(t, offsets[1], m.Config.CoreSize-m.Config.Distance)
+		seen[offsets[1]] = true
+	}
+	require.Greater(t, len(seen), 100)
+
+	m.Fixed = 4000
+	require.NoError(t, m.RoundOffsets(7, offsets))
+	require.Equal(t, []Address{0, 4000}, offsets)
+
+	require.Error(t, m.RoundOffsets(0, make([]Address, 3)))
+}
+
+func TestRoundOffsetsMultiWarrior(t *testing.T) {
+	m := newTestMatch(t)
+	m.Warriors = append(m.Warriors, m.Warriors...)
+	offsets := make([]Address, 4)
+	for round := 0; round < 100; round++ {
+		require.NoError(t, m.RoundOffsets(round, offsets))
+		for i := range offsets {
+			for j := range offsets {
+				if i == j {
+					continue
+				}
+				sep := (offsets[i] + m.Config.CoreSize - offsets[j]) % m.Config.CoreSize
+				require.GreaterOrEqual(t, sep, m.Config.Distance)
+			}
+		}
+	}
+
+	m.Config.Distance = 3000
+	require.Error(t, m.RoundOffsets(0, offsets))
+}
+
+func TestRunMatchErrors(t *testing.T) {
+	m := newTestMatch(t)
+	m.Warriors = nil
+	_, err := RunMatch(context.Background(), m)
+	require.Error(t, err)
+
+	m = newTestMatch(t)
+	m.Warriors = m.Warriors[:1]
+	m.Fixed = 100
+	_, err = RunMatch(context.Background(), m)
+	require.Error(t, err)
+
+	m = newTestMatch(t)
+	m.Warriors[0] = &WarriorData{Code: make([]Instruction, 200)}
+	_, err = RunMatch(context.Background(), m)
+	require.Error(t, err)
+
+	m = newTestMatch(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = RunMatch(ctx, m)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRunMatchPermute(t *testing.T) {
+	m := newTestMatch(t)
+	m.Config.CoreSize = 800
+	m.Config.ReadLimit = 800
+	m.Config.WriteLimit = 800
+	m.Config.Processes = 800
+	m.Config.Cycles = 8000
+	m.Config.Length = 20
+	m.Config.Distance = 20
+	m.Permute = true
+
+	require.Equal(t, 761, m.RoundCount())
+	result, err := RunMatch(context.Background(), m)
+	require.NoError(t, err)
+	require.Equal(t, 761, result.Rounds)
+	require.Len(t, result.Positions, 761)
+
+	wins := 0
+	for i, p := range result.Positions {
+		require.Equal(t, Address(20+i), p.Offset)
+		if p.Winner == 0 {
+			wins++
+		}
+	}
+	require.Equal(t, result.Wins[0], wins)
+
+	// permutation results do not depend on the seed or parallelism
+	m.Seed = 77
+	m.Workers = 1
+	serial, err := RunMatch(context.Background(), m)
+	require.NoError(t, err)
+	require.Equal(t, result, serial)
+
+	m.Stride = 100
+	require.Equal(t, 8, m.RoundCount())
+	strided, err := RunMatch(context.Background(), m)
+	require.NoError(t, err)
+	require.Len(t, strided.Positions, 8)
+	for i, p := range strided.Positions {
+		require.Equal(t, result.Positions[i*100], p)
+	}
+
+	offsets := make([]Address, 2)
+	require.NoError(t, m.RoundOffsets(7, offsets))
+	require.Equal(t, Address(720), offsets[1])
+	require.Error(t, m.RoundOffsets(8, offsets))
+
+	m.Fixed = 100
+	_, err = RunMatch(context.Background(), m)
+	require.Error(t, err)
+}
+
+func TestRunMatchRecordPositions(t *testing.T) {
+	m := newTestMatch(t)
+	m.Rounds = 10
+	m.RecordPositions = true
+	result, err := RunMatch(context.Background(), m)
+	require.NoError(t, err)
+	require.Len(t, result.Positions, 10)
+
+	offsets := make([]Address, 2)
+	for round, p := range result.Positions {
+		require.NoError(t, m.RoundOffsets(round, offsets))
+		require.Equal(t, offsets[1], p.Offset)
+	}
+
+	m.Warriors = append(m.Warriors, m.Warriors[0])
+	_, err = RunMatch(context.Background(), m)
+	require.Error(t, err)
+}
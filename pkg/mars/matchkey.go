@@ -0,0 +1,80 @@
+package mars
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// matchKeyVersion is changed whenever a change to the simulator would
+// change the results of a match with the same key
+const matchKeyVersion = 1
+
+// Key returns a canonical hash of everything that determines the results
+// of a match: the config, the code and start of each warrior, the rounds,
+// the positions and the seed. Warrior names and the number of workers are
+// not included. The seed is ignored if no warrior is placed randomly.
+//
+// The contents of a CoreImage file are included, so the key changes when
+// the file does. If the file can not be loaded only its name is included;
+// running the match then fails.
+func (m *Match) Key() string {
+	buf := &bytes.Buffer{}
+	e := snapshotEncoder{buf: buf}
+
+	e.string("gmars match")
+	e.uint(matchKeyVersion)
+	e.config(m.Config)
+	if m.Config.CoreFill == CoreFillImage {
+		e.coreImage(m.Config)
+	}
+
+	e.uint(uint64(m.RoundCount()))
+	seed := m.Seed
+	if m.Permute || m.Fixed != 0 || len(m.Warriors) < 2 {
+		seed = 0
+	}
+	e.uint(uint64(seed))
+	e.uint(uint64(m.Fixed))
+	if m.Permute {
+		e.uint(1)
+		e.uint(uint64(m.stride()))
+	} else {
+		e.uint(0)
+	}
+	if m.RecordPositions {
+		e.uint(1)
+	} else {
+		e.uint(0)
+	}
+
+	e.uint(uint64(len(m.Warriors)))
+	for _, w := range m.Warriors {
+		e.uint(uint64(w.Start))
+		e.uint(uint64(len(w.Code)))
+		for _, inst := range w.Code {
+			e.instruction(inst)
+		}
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// coreImage encodes the contents of the core image of a config, or a
+// marker if it can not be loaded
+func (e *snapshotEncoder) coreImage(config SimulatorConfig) {
+	var fill []cell
+	opcodes, err := newOpcodeSet(config.Opcodes)
+	if err == nil {
+		fill, err = newCoreFill(config, opcodes)
+	}
+	if err != nil {
+		e.uint(0)
+		return
+	}
+	e.uint(1)
+	for _, c := range fill {
+		e.instruction(c.instruction())
+	}
+}
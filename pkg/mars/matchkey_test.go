@@ -0,0 +1,74 @@
+package mars
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchKey(t *testing.T) {
+	m := newTestMatch(t)
+	key := m.Key()
+	require.Len(t, key, 64)
+
+	// names and workers do not change the results
+	same := m
+	renamed := *m.Warriors[0]
+	renamed.Name = "renamed"
+	same.Warriors = []*WarriorData{&renamed, m.Warriors[1]}
+	same.Workers = 4
+	require.Equal(t, key, same.Key())
+
+	changes := []func(m *Match){
+		func(m *Match) { m.Seed++ },
+		func(m *Match) { m.Rounds++ },
+		func(m *Match) { m.Config.Cycles++ },
+		func(m *Match) { m.Config.CoreFill = CoreFillRandom },
+		func(m *Match) { m.Fixed = 100 },
+		func(m *Match) { m.RecordPositions = true },
+		func(m *Match) { m.Warriors = []*WarriorData{m.Warriors[1], m.Warriors[0]} },
+		func(m *Match) {
+			w := *m.Warriors[0]
+			w.Start++
+			m.Warriors = []*WarriorData{&w, m.Warriors[1]}
+		},
+		func(m *Match) {
+			w := *m.Warriors[0]
+			w.Code = append([]Instruction{}, w.Code...)
+			w.Code[0].A++
+			m.Warriors = []*WarriorData{&w, m.Warriors[1]}
+		},
+	}
+	for i, change := range changes {
+		changed := m
+		change(&changed)
+		require.NotEqual(t, key, changed.Key(), "change %d", i)
+	}
+
+	// the seed is unused with fixed positions
+	fixed := m
+	fixed.Fixed = 100
+	fixedKey := fixed.Key()
+	fixed.Seed++
+	require.Equal(t, fixedKey, fixed.Key())
+}
+
+func TestMatchKeyCoreImage(t *testing.T) {
+	m := newTestMatch(t)
+	path := filepath.Join(t.TempDir(), "core.red")
+	require.NoError(t, os.WriteFile(path, []byte("JMP.B $ -1, $ 0\n"), 0o644))
+	m.Config.CoreFill = CoreFillImage
+	m.Config.CoreImage = path
+	key := m.Key()
+
+	// the key follows the contents of the image, not its name
+	require.NoError(t, os.WriteFile(path, []byte("JMP.B $ -2, $ 0\n"), 0o644))
+	require.NotEqual(t, key, m.Key())
+	require.NoError(t, os.WriteFile(path, []byte("JMP.B $ -1, $ 0\n"), 0o644))
+	require.Equal(t, key, m.Key())
+
+	require.NoError(t, os.Remove(path))
+	require.NotEqual(t, key, m.Key())
+}
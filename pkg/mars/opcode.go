@@ -0,0 +1,210 @@
+package mars
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// OpcodeHandler defines the parsing, validation and execution of an op code
+// outside the standard instruction set. Handlers are registered with
+// RegisterOpcode and enabled for a simulator by listing their names in
+// SimulatorConfig.Opcodes.
+//
+// When undo is enabled, every write and queued process of Exec is recorded
+// in the journal, so StepBack undoes extensions exactly. Handlers must only
+// change the core and queue through OpcodeContext.
+type OpcodeHandler interface {
+	// Code returns the op code, which must be greater than NOP
+	Code() OpCode
+
+	// Name returns the mnemonic used for the op code in load files
+	Name() string
+
+	// Validate returns an error if the op code can not be used with an op
+	// mode and address modes
+	Validate(mode OpMode, aMode, bMode AddressMode) error
+
+	// Exec executes an instruction after its operands have been evaluated.
+	// The task is terminated unless Exec queues at least one process.
+	Exec(ctx *OpcodeContext)
+}
+
+var opcodeRegistry = struct {
+	sync.RWMutex
+	byCode map[OpCode]OpcodeHandler
+	byName map[string]OpcodeHandler
+}{
+	byCode: make(map[OpCode]OpcodeHandler),
+	byName: make(map[string]OpcodeHandler),
+}
+
+// RegisterOpcode adds a handler to the registry of op code extensions. The
+// code and name must not be used by a standard or registered op code.
+func RegisterOpcode(h OpcodeHandler) error {
+	name := strings.ToLower(h.Name())
+	if h.Code() <= NOP {
+		return fmt.Errorf("op code %d is reserved", h.Code())
+	}
+	if _, err := getOpCode(name); err == nil {
+		return fmt.Errorf("op code name '%s' is reserved", name)
+	}
+
+	opcodeRegistry.Lock()
+	defer opcodeRegistry.Unlock()
+
+	if _, ok := opcodeRegistry.byCode[h.Code()]; ok {
+		return fmt.Errorf("op code %d already registered", h.Code())
+	}
+	if _, ok := opcodeRegistry.byName[name]; ok {
+		return fmt.Errorf("op code '%s' already registered", name)
+	}
+	opcodeRegistry.byCode[h.Code()] = h
+	opcodeRegistry.byName[name] = h
+	return nil
+}
+
+// LookupOpcode returns the registered handler with the given name
+func LookupOpcode(name string) (OpcodeHandler, bool) {
+	opcodeRegistry.RLock()
+	defer opcodeRegistry.RUnlock()
+	h, ok := opcodeRegistry.byName[strings.ToLower(name)]
+	return h, ok
+}
+
+func lookupOpcodeCode(op OpCode) (OpcodeHandler, bool) {
+	opcodeRegistry.RLock()
+	defer opcodeRegistry.RUnlock()
+	h, ok := opcodeRegistry.byCode[op]
+	return h, ok
+}
+
+// opcodeSet holds the op code extensions enabled by a configuration
+type opcodeSet map[OpCode]OpcodeHandler
+
+func newOpcodeSet(names []string) (opcodeSet, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	set := make(opcodeSet, len(names))
+	for _, name := range names {
+		h, ok := LookupOpcode(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown op code extension '%s'", name)
+		}
+		set[h.Code()] = h
+	}
+	return set, nil
+}
+
+func (set opcodeSet) byName(name string) (OpcodeHandler, bool) {
+	for _, h := range set {
+		if strings.EqualFold(h.Name(), name) {
+			return h, true
+		}
+	}
+	return nil, false
+}
+
+// opcodeHost is implemented by simulators to apply the effects of an
+// OpcodeHandler
+type opcodeHost interface {
+	extRead(a Address) Instruction
+	extWrite(w *warrior, a Address, inst Instruction)
+	extQueue(w *warrior, a Address)
+}
+
+// OpcodeContext holds the evaluated operands of an instruction executed by
+// an OpcodeHandler and gives access to the core and process queue
+type OpcodeContext struct {
+	PC Address
+	IR Instruction
+
+	// IRA and IRB are the instructions referenced by the A and B operands
+	IRA Instruction
+	IRB Instruction
+
+	// RAB is the address referenced by the A operand and WAB is the address
+	// written by the B operand
+	RAB Address
+	WAB Address
+
+	coreSize Address
+	host     opcodeHost
+	w        *warrior
+}
+
+// CoreSize returns the size of the core
+func (c *OpcodeContext) CoreSize() Address {
+	return c.coreSize
+}
+
+// Read returns the instruction at a core address
+func (c *OpcodeContext) Read(a Address) Instruction {
+	return c.host.extRead(a % c.coreSize)
+}
+
+// Write replaces the instruction at a core address. Any number of
+// addresses may be written.
+func (c *OpcodeContext) Write(a Address, inst Instruction) {
+	c.host.extWrite(c.w, a%c.coreSize, inst)
+}
+
+// Queue adds a process at a core address to the executing warrior's queue.
+// Any number of processes may be queued, subject to the queue policy.
+func (c *OpcodeContext) Queue(a Address) {
+	c.host.extQueue(c.w, a%c.coreSize)
+}
+
+func (s *simState) extRead(a Address) Instruction {
+	return s.mem[a].instruction()
+}
+
+func (s *simState) extWrite(w *warrior, a Address, inst Instruction) {
+	if s.journal != nil {
+		s.journalCell(a)
+	}
+	inst.A %= s.m
+	inst.B %= s.m
+	s.mem[a] = newCell(inst)
+}
+
+func (s *simState) extQueue(w *warrior, a Address) {
+	if s.journal != nil {
+		s.journalPush(w)
+	}
+	w.pq.Push(a)
+}
+
+func (s *reportSim) extWrite(w *warrior, a Address, inst Instruction) {
+	s.simState.extWrite(w, a, inst)
+	s.reportAccess(WarriorWrite, w, a, FieldInstruction, OperandNone)
+}
+
+func (s *reportSim) extQueue(w *warrior, a Address) {
+	if s.journal != nil {
+		s.journalPush(w)
+	}
+	s.push(w, a)
+}
+
+// execExtension executes IR with an enabled op code extension and returns
+// false if the op code is not enabled
+func (s *simState) execExtension(host opcodeHost, w *warrior, PC, RAB, WAB Address, IR, IRA, IRB cell) bool {
+	h, ok := s.opcodes[IR.Op]
+	if !ok {
+		return false
+	}
+	h.Exec(&OpcodeContext{
+		PC:       PC,
+		IR:       IR.instruction(),
+		IRA:      IRA.instruction(),
+		IRB:      IRB.instruction(),
+		RAB:      RAB,
+		WAB:      WAB,
+		coreSize: s.m,
+		host:     host,
+		w:        w,
+	})
+	return true
+}
@@ -0,0 +1,184 @@
+package mars
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	XCH = NOP + 1
+	HLT = NOP + 2
+	SPR = NOP + 3
+)
+
+// xchOpcode swaps the A and B fields of the B operand's target
+type xchOpcode struct{}
+
+func (xchOpcode) Code() OpCode { return XCH }
+func (xchOpcode) Name() string { return "xch" }
+
+func (xchOpcode) Validate(mode OpMode, aMode, bMode AddressMode) error {
+	if bMode == IMMEDIATE {
+		return fmt.Errorf("invalid b mode '#' for op 'xch'")
+	}
+	return nil
+}
+
+func (xchOpcode) Exec(ctx *OpcodeContext) {
+	inst := ctx.IRB
+	inst.A, inst.B = inst.B, inst.A
+	ctx.Write(ctx.WAB, inst)
+	ctx.Queue(ctx.PC + 1)
+}
+
+// hltOpcode terminates the task
+type hltOpcode struct{}
+
+func (hltOpcode) Code() OpCode                                    { return HLT }
+func (hltOpcode) Name() string                                    { return "hlt" }
+func (hltOpcode) Validate(OpMode, AddressMode, AddressMode) error { return nil }
+func (hltOpcode) Exec(ctx *OpcodeContext)                         {}
+
+// sprOpcode writes its A operand instruction to the five cells after the B
+// operand's target and queues a process at each of them
+type sprOpcode struct{}
+
+func (sprOpcode) Code() OpCode                                    { return SPR }
+func (sprOpcode) Name() string                                    { return "spr" }
+func (sprOpcode) Validate(OpMode, AddressMode, AddressMode) error { return nil }
+
+func (sprOpcode) Exec(ctx *OpcodeContext) {
+	for i := Address(1); i <= 5; i++ {
+		ctx.Write(ctx.WAB+i, ctx.IRA)
+		ctx.Queue(ctx.WAB + i)
+	}
+}
+
+var registerTestOpcodes sync.Once
+
+func testOpcodeConfig(t *testing.T) SimulatorConfig {
+	registerTestOpcodes.Do(func() {
+		require.NoError(t, RegisterOpcode(xchOpcode{}))
+		require.NoError(t, RegisterOpcode(hltOpcode{}))
+		require.NoError(t, RegisterOpcode(sprOpcode{}))
+	})
+	config := ConfigNOP94()
+	config.Opcodes = []string{"xch", "hlt", "spr"}
+	return config
+}
+
+func TestRegisterOpcodeErrors(t *testing.T) {
+	testOpcodeConfig(t)
+
+	require.Error(t, RegisterOpcode(xchOpcode{}))
+	require.Error(t, RegisterOpcode(namedOpcode{code: NOP, name: "foo"}))
+	require.Error(t, RegisterOpcode(namedOpcode{code: NOP + 10, name: "mov"}))
+	require.Error(t, RegisterOpcode(namedOpcode{code: XCH, name: "foo"}))
+	require.Error(t, RegisterOpcode(namedOpcode{code: NOP + 10, name: "XCH"}))
+
+	h, ok := LookupOpcode("XCH")
+	require.True(t, ok)
+	require.Equal(t, XCH, h.Code())
+	require.Equal(t, "XCH", XCH.String())
+}
+
+type namedOpcode struct {
+	hltOpcode
+	code OpCode
+	name string
+}
+
+func (o namedOpcode) Code() OpCode { return o.code }
+func (o namedOpcode) Name() string { return o.name }
+
+func TestOpcodeConfig(t *testing.T) {
+	config := testOpcodeConfig(t)
+	require.NoError(t, config.Validate())
+
+	config.Opcodes = []string{"xyz"}
+	require.Error(t, config.Validate())
+	_, err := NewSimulator(config)
+	require.Error(t, err)
+}
+
+func TestOpcodeParse(t *testing.T) {
+	config := testOpcodeConfig(t)
+
+	data, err := ParseLoadFile(strings.NewReader("XCH.I $ 0, $ 1\nhlt.f $ 0, $ 0\n"), config)
+	require.NoError(t, err)
+	require.Equal(t, []Instruction{
+		{Op: XCH, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1},
+		{Op: HLT, OpMode: F, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+	}, data.Code)
+
+	// rejected by the handler's mode validation
+	_, err = ParseLoadFile(strings.NewReader("xch.i $ 0, # 1\n"), config)
+	require.Error(t, err)
+
+	// not enabled by the config
+	_, err = ParseLoadFile(strings.NewReader("xch.i $ 0, $ 1\n"), ConfigNOP94())
+	require.Error(t, err)
+}
+
+func TestOpcodeExec(t *testing.T) {
+	config := testOpcodeConfig(t)
+	code := []Instruction{
+		{Op: XCH, OpMode: I, AMode: DIRECT, BMode: DIRECT, B: 3},
+		{Op: XCH, OpMode: I, AMode: DIRECT, BMode: B_INDIRECT, B: 2},
+		{Op: HLT, OpMode: F},
+		{Op: DAT, OpMode: F, AMode: IMMEDIATE, A: 4, BMode: IMMEDIATE, B: 1},
+	}
+
+	for _, newSim := range []func(SimulatorConfig) (Simulator, error){NewSimulator, NewFastSimulator} {
+		sim, err := newSim(config)
+		require.NoError(t, err)
+		sim.EnableUndo(10)
+		_, err = sim.AddWarrior(&WarriorData{Code: code})
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(0, 0))
+
+		require.Equal(t, 1, sim.RunCycle())
+		require.Equal(t, Instruction{Op: DAT, OpMode: F, AMode: IMMEDIATE, A: 1, BMode: IMMEDIATE, B: 4}, sim.GetMem(3))
+
+		// the B pointer at 3 now points to 7
+		require.Equal(t, 1, sim.RunCycle())
+		require.Equal(t, Instruction{}, sim.GetMem(7))
+
+		require.Equal(t, 0, sim.RunCycle())
+		require.Equal(t, CauseExtension, sim.Result().Warriors[0].DeathCause)
+
+		require.Equal(t, 3, sim.StepBack(3))
+		require.Equal(t, code[3], sim.GetMem(3))
+	}
+}
+
+func TestOpcodeReports(t *testing.T) {
+	config := testOpcodeConfig(t)
+	sim, err := newReportSim(config)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{
+		{Op: XCH, OpMode: I, AMode: DIRECT, BMode: DIRECT, B: 1},
+		{Op: HLT, OpMode: F},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	reports := reportRecorder{}
+	sim.AddReporter(&reports)
+	sim.RunCycle()
+	sim.RunCycle()
+
+	types := []ReportType{}
+	for _, r := range reports {
+		switch r.Type {
+		case WarriorWrite, WarriorTaskTerminate, WarriorTerminate:
+			types = append(types, r.Type)
+			require.Equal(t, Address(1), r.Address)
+		}
+	}
+	require.Equal(t, []ReportType{WarriorWrite, WarriorTaskTerminate, WarriorTerminate}, types)
+}
@@ -0,0 +1,76 @@
+package mars
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var presetRegistry = struct {
+	sync.RWMutex
+	configs map[string]SimulatorConfig
+}{
+	configs: map[string]SimulatorConfig{
+		"94":           ConfigNOP94(),
+		"94nop":        ConfigNOP94(),
+		"88":           ConfigKOTH88(),
+		"nano":         NewQuickConfig(ICWS94, 80, 80, 800, 5),
+		"tiny":         NewQuickConfig(ICWS94, 800, 800, 8000, 20),
+		"lp":           NewQuickConfig(ICWS94, 8000, 8, 80000, 200),
+		"multiwarrior": ConfigNOP94(),
+		"experimental": NewQuickConfig(ICWS94, 55440, 10000, 500000, 200),
+	},
+}
+
+// Preset returns the configuration of a hill by name. The names are not
+// case sensitive. The built in presets match the published settings of
+// these hills:
+//
+//	94, 94nop     '94 draft, core 8000, 8000 processes, 80000 cycles
+//	88            '88 standard with the same limits as 94
+//	nano          core 80, 80 processes, 800 cycles, length 5
+//	tiny          core 800, 800 processes, 8000 cycles, length 20
+//	lp            limited process, core 8000, 8 processes, length 200
+//	multiwarrior  same limits as 94, played with many warriors per round
+//	experimental  core 55440, 10000 processes, 500000 cycles, length 200
+func Preset(name string) (SimulatorConfig, error) {
+	presetRegistry.RLock()
+	defer presetRegistry.RUnlock()
+
+	config, ok := presetRegistry.configs[strings.ToLower(name)]
+	if !ok {
+		return SimulatorConfig{}, fmt.Errorf("unknown preset '%s'", name)
+	}
+	config.Opcodes = append([]string(nil), config.Opcodes...)
+	return config, nil
+}
+
+// RegisterPreset adds or replaces a named configuration
+func RegisterPreset(name string, config SimulatorConfig) error {
+	if name == "" {
+		return fmt.Errorf("empty preset name")
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	presetRegistry.Lock()
+	defer presetRegistry.Unlock()
+	config.Opcodes = append([]string(nil), config.Opcodes...)
+	presetRegistry.configs[strings.ToLower(name)] = config
+	return nil
+}
+
+// PresetNames returns the sorted names of the registered presets
+func PresetNames() []string {
+	presetRegistry.RLock()
+	defer presetRegistry.RUnlock()
+
+	names := make([]string, 0, len(presetRegistry.configs))
+	for name := range presetRegistry.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,43 @@
+package mars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresets(t *testing.T) {
+	for _, name := range []string{"94", "94nop", "88", "nano", "tiny", "lp", "multiwarrior", "experimental"} {
+		config, err := Preset(name)
+		require.NoError(t, err, name)
+		require.NoError(t, config.Validate(), name)
+	}
+
+	nano, err := Preset("NANO")
+	require.NoError(t, err)
+	require.Equal(t, NewQuickConfig(ICWS94, 80, 80, 800, 5), nano)
+
+	lp, err := Preset("lp")
+	require.NoError(t, err)
+	require.Equal(t, Address(8), lp.Processes)
+
+	koth88, err := Preset("88")
+	require.NoError(t, err)
+	require.Equal(t, ICWS88, koth88.Mode)
+
+	_, err = Preset("unknown")
+	require.Error(t, err)
+}
+
+func TestRegisterPreset(t *testing.T) {
+	config := NewQuickConfig(ICWS94, 400, 40, 4000, 10)
+	require.NoError(t, RegisterPreset("Test400", config))
+	require.Contains(t, PresetNames(), "test400")
+
+	out, err := Preset("test400")
+	require.NoError(t, err)
+	require.Equal(t, config, out)
+
+	require.Error(t, RegisterPreset("", config))
+	require.Error(t, RegisterPreset("invalid", SimulatorConfig{}))
+}
@@ -2,20 +2,49 @@ package mars
 
 import "fmt"
 
+// QueuePolicy selects what happens when a process is pushed to a full
+// process queue
+type QueuePolicy uint8
+
+const (
+	// QueueDropNew discards the new process
+	QueueDropNew QueuePolicy = iota
+	// QueueDropOldest discards the process at the front of the queue to make
+	// room for the new process
+	QueueDropOldest
+)
+
+func (p QueuePolicy) String() string {
+	switch p {
+	case QueueDropNew:
+		return "drop new"
+	case QueueDropOldest:
+		return "drop oldest"
+	default:
+		return "?"
+	}
+}
+
 type processQueue struct {
 	queue  []Address
 	size   Address
 	length Address
 	start  Address
 	end    Address
+	policy QueuePolicy
+
+	// dropped counts the processes discarded because the queue was full
+	dropped     Address
+	lastDropped Address
 }
 
-func newProcessQueue(size Address) *processQueue {
+func newProcessQueue(size Address, policy QueuePolicy) *processQueue {
 	queue := make([]Address, size)
 
 	return &processQueue{
-		queue: queue,
-		size:  size,
+		queue:  queue,
+		size:   size,
+		policy: policy,
 	}
 }
 
@@ -23,13 +52,40 @@ func (q *processQueue) Len() Address {
 	return q.length
 }
 
-func (q *processQueue) Push(a Address) {
+// Push adds a process to the end of the queue. If the queue is full, a
+// process is discarded according to the queue policy and Push returns true.
+func (q *processQueue) Push(a Address) bool {
+	dropped := false
 	if q.length >= q.size {
-		return
+		dropped = true
+		q.dropped++
+		if q.policy != QueueDropOldest {
+			q.lastDropped = a
+			return true
+		}
+		q.lastDropped = q.queue[q.start]
+		q.start++
+		if q.start == q.size {
+			q.start = 0
+		}
+		q.length--
 	}
 	q.queue[q.end] = a
-	q.end = (q.end + 1) % q.size
+	q.end++
+	if q.end == q.size {
+		q.end = 0
+	}
 	q.length++
+	return dropped
+}
+
+// reset empties the queue and clears the dropped counters
+func (q *processQueue) reset() {
+	q.length = 0
+	q.start = 0
+	q.end = 0
+	q.dropped = 0
+	q.lastDropped = 0
 }
 
 func (q *processQueue) Pop() (Address, error) {
@@ -37,7 +93,10 @@ func (q *processQueue) Pop() (Address, error) {
 		return 0, fmt.Errorf("pull from empty queue")
 	}
 	val := q.queue[q.start]
-	q.start = (q.start + 1) % q.size
+	q.start++
+	if q.start == q.size {
+		q.start = 0
+	}
 	q.length--
 	return val, nil
 }
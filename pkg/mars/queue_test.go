@@ -7,17 +7,19 @@ import (
 )
 
 func TestQueueEmpty(t *testing.T) {
-	pq := newProcessQueue(2)
+	pq := newProcessQueue(2, QueueDropNew)
 	_, err := pq.Pop()
 	require.Error(t, err)
 }
 
 func TestQueue(t *testing.T) {
-	pq := newProcessQueue(2)
+	pq := newProcessQueue(2, QueueDropNew)
 
-	pq.Push(1)
-	pq.Push(2)
-	pq.Push(3)
+	require.False(t, pq.Push(1))
+	require.False(t, pq.Push(2))
+	require.True(t, pq.Push(3))
+	require.Equal(t, Address(1), pq.dropped)
+	require.Equal(t, Address(3), pq.lastDropped)
 
 	out, err := pq.Pop()
 	require.NoError(t, err)
@@ -30,3 +32,14 @@ func TestQueue(t *testing.T) {
 	_, err = pq.Pop()
 	require.Error(t, err)
 }
+
+func TestQueueDropOldest(t *testing.T) {
+	pq := newProcessQueue(2, QueueDropOldest)
+
+	require.False(t, pq.Push(1))
+	require.False(t, pq.Push(2))
+	require.True(t, pq.Push(3))
+	require.Equal(t, Address(1), pq.dropped)
+	require.Equal(t, Address(1), pq.lastDropped)
+	require.Equal(t, []Address{2, 3}, pq.Values())
+}
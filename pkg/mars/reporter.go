@@ -18,13 +18,114 @@ const (
 	WarriorWrite
 	WarriorDecrement
 	WarriorIncrement
+	SimRestore
+	WarriorTaskDropped
+	WarriorExecute
 )
 
+// Field identifies a part of a core address
+type Field uint8
+
+const (
+	FieldInstruction Field = iota
+	FieldA
+	FieldB
+)
+
+// TerminateCause describes why a task was terminated
+type TerminateCause uint8
+
+const (
+	CauseNone TerminateCause = iota
+	// CauseDAT means a DAT instruction was executed
+	CauseDAT
+	// CauseDivZero means a DIV instruction divided by zero
+	CauseDivZero
+	// CauseModZero means a MOD instruction divided by zero
+	CauseModZero
+	// CauseIllegal means an instruction with an unknown op code was executed
+	CauseIllegal
+	// CauseExtension means an op code extension did not queue a process
+	CauseExtension
+)
+
+func (c TerminateCause) String() string {
+	switch c {
+	case CauseNone:
+		return "none"
+	case CauseDAT:
+		return "DAT executed"
+	case CauseDivZero:
+		return "DIV by zero"
+	case CauseModZero:
+		return "MOD by zero"
+	case CauseIllegal:
+		return "illegal instruction"
+	case CauseExtension:
+		return "extension terminated"
+	default:
+		return "?"
+	}
+}
+
+// terminateCause returns the cause of a task terminating after executing IR
+func (s *simState) terminateCause(IR cell) TerminateCause {
+	if _, ok := s.opcodes[IR.Op]; ok {
+		return CauseExtension
+	}
+
+	switch IR.Op {
+	case DAT:
+		return CauseDAT
+	case DIV:
+		return CauseDivZero
+	case MOD:
+		return CauseModZero
+	default:
+		return CauseIllegal
+	}
+}
+
 type Report struct {
 	Type         ReportType
 	Cycle        int
 	WarriorIndex int
 	Address      Address
+
+	// Field and Operand are set for WarriorRead, WarriorWrite,
+	// WarriorIncrement and WarriorDecrement reports to the accessed field
+	// and the operand responsible for the access
+	Field   Field
+	Operand Operand
+
+	// Cause and Instruction are set for WarriorTaskTerminate and
+	// WarriorTerminate reports to the cause and the executed instruction.
+	// Instruction is also set for WarriorExecute reports.
+	Cause       TerminateCause
+	Instruction Instruction
+
+	// Exec is set for WarriorExecute reports. It is reused by the
+	// simulator and is only valid during the call to Report.
+	Exec *ExecInfo
+}
+
+// ExecInfo holds the decoded operands of an executed instruction
+type ExecInfo struct {
+	// RPA, WPA, RPB and WPB are the core addresses referenced by the A and B
+	// operands through the read and write limits
+	RPA Address
+	WPA Address
+	RPB Address
+	WPB Address
+
+	// IRA and IRB are copies of the instructions referenced by the A and B
+	// operands, taken as each operand was evaluated
+	IRA Instruction
+	IRB Instruction
+
+	// Queued holds the processes pushed to the warrior's queue, including
+	// any that were dropped because the queue was full
+	Queued []Address
 }
 
 type Reporter interface {
@@ -43,6 +144,8 @@ func (r *debugReporter) Report(report Report) {
 	switch report.Type {
 	case SimReset:
 		fmt.Printf("Simulator reset\n")
+	case SimRestore:
+		fmt.Printf("Simulator restored at cycle %d\n", report.Cycle)
 	case CycleStart:
 		fmt.Printf("%d\n", r.s.CycleCount())
 	case WarriorSpawn:
@@ -50,16 +153,20 @@ func (r *debugReporter) Report(report Report) {
 	case WarriorTaskPop:
 		fmt.Printf("W%02d %04d: %s\n", report.WarriorIndex, report.Address, r.s.GetMem(report.Address).NormString(r.s.CoreSize()))
 	case WarriorTaskTerminate:
-		fmt.Printf("W%02d %04d: Task Terminated\n", report.WarriorIndex, report.Address)
+		fmt.Printf("W%02d %04d: Task Terminated (%s: %s)\n", report.WarriorIndex, report.Address, report.Cause, report.Instruction.NormString(r.s.CoreSize()))
+	case WarriorExecute:
+		fmt.Printf("W%02d %04d: Execute A=%04d B=%04d queued %v\n", report.WarriorIndex, report.Address, report.Exec.RPA, report.Exec.WPB, report.Exec.Queued)
+	case WarriorTaskDropped:
+		fmt.Printf("W%02d %04d: Task Dropped\n", report.WarriorIndex, report.Address)
 	case WarriorTerminate:
-		fmt.Printf("W%02d %04d: Warrior Terminated\n", report.WarriorIndex, report.Address)
+		fmt.Printf("W%02d %04d: Warrior Terminated (%s)\n", report.WarriorIndex, report.Address, report.Cause)
 	case WarriorRead:
-		fmt.Printf("W%02d %04d: Read\n", report.WarriorIndex, report.Address)
+		fmt.Printf("W%02d %04d: Read %s (%s operand)\n", report.WarriorIndex, report.Address, report.Field, report.Operand)
 	case WarriorWrite:
-		fmt.Printf("W%02d %04d: Write\n", report.WarriorIndex, report.Address)
+		fmt.Printf("W%02d %04d: Write %s (%s operand)\n", report.WarriorIndex, report.Address, report.Field, report.Operand)
 	case WarriorIncrement:
-		fmt.Printf("W%02d %04d: Increment\n", report.WarriorIndex, report.Address)
+		fmt.Printf("W%02d %04d: Increment %s (%s operand)\n", report.WarriorIndex, report.Address, report.Field, report.Operand)
 	case WarriorDecrement:
-		fmt.Printf("W%02d %04d: Decrement\n", report.WarriorIndex, report.Address)
+		fmt.Printf("W%02d %04d: Decrement %s (%s operand)\n", report.WarriorIndex, report.Address, report.Field, report.Operand)
 	}
 }
@@ -0,0 +1,120 @@
+package mars
+
+// TerminationReason describes why a battle ended
+type TerminationReason uint8
+
+const (
+	// TerminationCycleLimit means the maximum number of cycles was reached
+	// with more than one warrior alive, or with the only warrior alive in a
+	// single warrior battle
+	TerminationCycleLimit TerminationReason = iota
+	// TerminationLastSurvivor means one warrior remains in a battle with
+	// more than one warrior
+	TerminationLastSurvivor
+	// TerminationAllDead means no warriors remain alive
+	TerminationAllDead
+)
+
+func (r TerminationReason) String() string {
+	switch r {
+	case TerminationCycleLimit:
+		return "cycle limit"
+	case TerminationLastSurvivor:
+		return "last survivor"
+	case TerminationAllDead:
+		return "all dead"
+	default:
+		return "?"
+	}
+}
+
+// WarriorResult holds the outcome of a battle for a single warrior
+type WarriorResult struct {
+	Alive bool
+	// DeathCycle is the cycle the warrior died on, or -1 if it is alive
+	DeathCycle int
+	// DeathCause is the cause of the last task terminating if the warrior
+	// is dead
+	DeathCause TerminateCause
+	// Processes is the number of processes at the end of the battle
+	Processes Address
+	// PeakProcesses is the highest number of processes during the battle
+	PeakProcesses Address
+	// Dropped is the number of processes discarded because the process
+	// queue was full
+	Dropped Address
+}
+
+// BattleResult holds the outcome of a battle
+type BattleResult struct {
+	// Winner is the index of the only surviving warrior in a battle with
+	// more than one warrior, or -1 otherwise
+	Winner int
+	// Tie is true if more than one warrior survived
+	Tie      bool
+	Cycles   int
+	Reason   TerminationReason
+	Warriors []WarriorResult
+}
+
+// Survivors returns whether each warrior is alive
+func (r BattleResult) Survivors() []bool {
+	out := make([]bool, len(r.Warriors))
+	for i, w := range r.Warriors {
+		out[i] = w.Alive
+	}
+	return out
+}
+
+// Result returns the result of the battle in its current state
+func (s *simState) Result() BattleResult {
+	result := BattleResult{}
+	s.ResultInto(&result)
+	return result
+}
+
+// ResultInto stores the result of the battle in its current state in r,
+// reusing the Warriors slice of r if it has enough capacity
+func (s *simState) ResultInto(r *BattleResult) {
+	warriors := r.Warriors
+	if cap(warriors) < len(s.warriors) {
+		warriors = make([]WarriorResult, len(s.warriors))
+	}
+	*r = BattleResult{
+		Winner:   -1,
+		Cycles:   int(s.cycleCount),
+		Warriors: warriors[:len(s.warriors)],
+	}
+
+	nAlive := 0
+	for i, w := range s.warriors {
+		r.Warriors[i] = WarriorResult{
+			Alive:         w.Alive(),
+			DeathCycle:    w.deathCycle,
+			DeathCause:    w.deathCause,
+			Processes:     w.ThreadCount(),
+			PeakProcesses: w.peakProcs,
+			Dropped:       w.dropped(),
+		}
+		if w.Alive() {
+			nAlive++
+			r.Warriors[i].DeathCycle = -1
+		}
+	}
+
+	switch {
+	case nAlive == 0:
+		r.Reason = TerminationAllDead
+	case nAlive == 1 && len(s.warriors) > 1:
+		r.Reason = TerminationLastSurvivor
+		for i, w := range r.Warriors {
+			if w.Alive {
+				r.Winner = i
+			}
+		}
+	default:
+		r.Reason = TerminationCycleLimit
+		r.Tie = nAlive > 1
+	}
+
+}
@@ -0,0 +1,153 @@
+package mars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultLastSurvivor(t *testing.T) {
+	for _, newSim := range []func(SimulatorConfig) (Simulator, error){NewSimulator, NewFastSimulator} {
+		sim, err := newSim(ConfigNOP94())
+		require.NoError(t, err)
+		_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{{Op: MOV, OpMode: I, B: 1}}})
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(0, 0))
+		_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{{Op: DAT, OpMode: F}}})
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(1, 4000))
+
+		result := sim.Run()
+		require.Equal(t, 0, result.Winner)
+		require.False(t, result.Tie)
+		require.Equal(t, TerminationLastSurvivor, result.Reason)
+		require.Equal(t, 2, result.Cycles)
+		require.Equal(t, WarriorResult{Alive: true, DeathCycle: -1, Processes: 1, PeakProcesses: 1}, result.Warriors[0])
+		require.Equal(t, WarriorResult{Alive: false, DeathCycle: 1, DeathCause: CauseDAT, Processes: 0, PeakProcesses: 1}, result.Warriors[1])
+	}
+}
+
+func TestResultAllDead(t *testing.T) {
+	sim, err := NewFastSimulator(ConfigNOP94())
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{{Op: DAT, OpMode: F}}})
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	result := sim.Run()
+	require.Equal(t, -1, result.Winner)
+	require.Equal(t, TerminationAllDead, result.Reason)
+	require.Equal(t, 0, result.Warriors[0].DeathCycle)
+}
+
+func TestResultPeakProcesses(t *testing.T) {
+	config := ConfigNOP94()
+	config.Processes = 64
+	config.Cycles = 10000
+	sim, err := NewFastSimulator(config)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{
+		{Op: SPL, OpMode: B, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+		{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	result := sim.Run()
+	require.Equal(t, TerminationCycleLimit, result.Reason)
+	require.Equal(t, Address(64), result.Warriors[0].PeakProcesses)
+	require.Equal(t, 10000, result.Cycles)
+}
+
+type reportCounter map[ReportType]int
+
+func (c reportCounter) Report(r Report) {
+	c[r.Type]++
+}
+
+func TestResultDropped(t *testing.T) {
+	for _, policy := range []QueuePolicy{QueueDropNew, QueueDropOldest} {
+		config := ConfigNOP94()
+		config.Processes = 4
+		config.Cycles = 100
+		config.QueuePolicy = policy
+		sim, err := NewReportingSimulator(config)
+		require.NoError(t, err)
+		counter := reportCounter{}
+		sim.AddReporter(counter)
+
+		_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{
+			{Op: SPL, OpMode: B, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+			{Op: JMP, OpMode: B, AMode: DIRECT, A: 8000 - 1, BMode: DIRECT, B: 0},
+		}})
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(0, 0))
+
+		result := sim.Run()
+		require.Greater(t, result.Warriors[0].Dropped, Address(0))
+		require.Equal(t, int(result.Warriors[0].Dropped), counter[WarriorTaskDropped])
+		require.Equal(t, Address(4), result.Warriors[0].Processes)
+	}
+}
+
+type reportRecorder []Report
+
+func (r *reportRecorder) Report(report Report) {
+	*r = append(*r, report)
+}
+
+func TestTerminateCause(t *testing.T) {
+	tests := []struct {
+		inst  Instruction
+		cause TerminateCause
+	}{
+		{Instruction{Op: DAT, OpMode: F, AMode: IMMEDIATE, BMode: IMMEDIATE, B: 3}, CauseDAT},
+		{Instruction{Op: DIV, OpMode: A, AMode: IMMEDIATE, BMode: DIRECT, B: 1}, CauseDivZero},
+		{Instruction{Op: DIV, OpMode: F, AMode: IMMEDIATE, A: 1, BMode: DIRECT, B: 0}, CauseDivZero},
+		{Instruction{Op: MOD, OpMode: AB, AMode: IMMEDIATE, BMode: DIRECT, B: 1}, CauseModZero},
+		{Instruction{Op: NOP + 1, OpMode: F}, CauseIllegal},
+		{Instruction{Op: JMZ, OpMode: I + 1}, CauseIllegal},
+		{Instruction{Op: JMN, OpMode: I + 1}, CauseIllegal},
+		{Instruction{Op: DJN, OpMode: I + 1}, CauseIllegal},
+		{Instruction{Op: CMP, OpMode: I + 1}, CauseIllegal},
+		{Instruction{Op: SEQ, OpMode: I + 1}, CauseIllegal},
+		{Instruction{Op: SLT, OpMode: I + 1}, CauseIllegal},
+		{Instruction{Op: SNE, OpMode: I + 1}, CauseIllegal},
+	}
+
+	for i, test := range tests {
+		sim, err := NewReportingSimulator(ConfigNOP94())
+		require.NoError(t, err)
+		reports := &reportRecorder{}
+		sim.AddReporter(reports)
+		_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{test.inst}})
+		require.NoError(t, err)
+		require.NoError(t, sim.SpawnWarrior(0, 100))
+
+		result := sim.Run()
+		require.Equal(t, test.cause, result.Warriors[0].DeathCause, i)
+
+		var task, warrior *Report
+		for i, r := range *reports {
+			switch r.Type {
+			case WarriorTaskTerminate:
+				task = &(*reports)[i]
+			case WarriorTerminate:
+				warrior = &(*reports)[i]
+			}
+		}
+		require.NotNil(t, task, i)
+		require.Equal(t, test.cause, task.Cause, i)
+		require.Equal(t, test.inst, task.Instruction)
+		require.Equal(t, Address(100), task.Address)
+		require.NotNil(t, warrior)
+		require.Equal(t, test.cause, warrior.Cause)
+
+		fast, err := NewFastSimulator(ConfigNOP94())
+		require.NoError(t, err)
+		_, err = fast.AddWarrior(&WarriorData{Code: []Instruction{test.inst}})
+		require.NoError(t, err)
+		require.NoError(t, fast.SpawnWarrior(0, 100))
+		require.Equal(t, test.cause, fast.Run().Warriors[0].DeathCause, i)
+	}
+}
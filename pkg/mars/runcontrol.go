@@ -0,0 +1,132 @@
+package mars
+
+import "context"
+
+// StopReason describes why RunContext, RunUntil or RunCycles returned
+type StopReason uint8
+
+const (
+	// StopDecided means one warrior remains in a battle with more than one
+	// warrior, or the only warrior in a single warrior battle has died
+	StopDecided StopReason = iota
+	// StopCycleLimit means the configured maximum number of cycles was reached
+	StopCycleLimit
+	// StopCycles means the number of cycles passed to RunCycles were run
+	StopCycles
+	// StopPredicate means the predicate passed to RunUntil returned true
+	StopPredicate
+	// StopCancelled means the context passed to RunContext was cancelled
+	StopCancelled
+	// StopBreakpoint means a Debugger breakpoint or watchpoint fired
+	StopBreakpoint
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopDecided:
+		return "decided"
+	case StopCycleLimit:
+		return "cycle limit"
+	case StopCycles:
+		return "cycles"
+	case StopPredicate:
+		return "predicate"
+	case StopCancelled:
+		return "cancelled"
+	case StopBreakpoint:
+		return "breakpoint"
+	default:
+		return "?"
+	}
+}
+
+// decided returns true if the battle is over with aliveCount warriors alive
+func (s *simState) decided(aliveCount int) bool {
+	nWarriors := len(s.warriors)
+	return (nWarriors == 1 && aliveCount == 0) || (nWarriors > 1 && aliveCount <= 1)
+}
+
+func (s *simState) aliveCount() int {
+	n := 0
+	for _, w := range s.warriors {
+		if w.state == WarriorAlive {
+			n++
+		}
+	}
+	return n
+}
+
+// runUntil calls runCycle until the battle is decided, the cycle limit is
+// reached or stop returns true after a cycle
+func (s *simState) runUntil(runCycle func() int, stop func() (StopReason, bool)) StopReason {
+	if len(s.warriors) == 0 || s.decided(s.aliveCount()) {
+		return StopDecided
+	}
+
+	for {
+		if s.cycleCount >= s.maxCycles {
+			return StopCycleLimit
+		}
+
+		if s.decided(runCycle()) {
+			return StopDecided
+		}
+
+		if reason, ok := stop(); ok {
+			return reason
+		}
+	}
+}
+
+func (s *simState) runContext(ctx context.Context, runCycle func() int) StopReason {
+	done := ctx.Done()
+	return s.runUntil(runCycle, func() (StopReason, bool) {
+		select {
+		case <-done:
+			return StopCancelled, true
+		default:
+			return 0, false
+		}
+	})
+}
+
+func (s *simState) runCycles(n int, runCycle func() int) StopReason {
+	if n <= 0 {
+		return StopCycles
+	}
+	count := 0
+	return s.runUntil(runCycle, func() (StopReason, bool) {
+		count++
+		return StopCycles, count >= n
+	})
+}
+
+func (s *simState) runPredicate(sim Simulator, pred func(Simulator) bool, runCycle func() int) StopReason {
+	return s.runUntil(runCycle, func() (StopReason, bool) {
+		return StopPredicate, pred(sim)
+	})
+}
+
+func (s *reportSim) RunContext(ctx context.Context) StopReason {
+	return s.runContext(ctx, s.RunCycle)
+}
+
+func (s *reportSim) RunUntil(pred func(Simulator) bool) StopReason {
+	return s.runPredicate(s, pred, s.RunCycle)
+}
+
+func (s *reportSim) RunCycles(n int) StopReason {
+	return s.runCycles(n, s.RunCycle)
+}
+
+func (s *fastSim) RunContext(ctx context.Context) StopReason {
+	return s.runContext(ctx, s.RunCycle)
+}
+
+func (s *fastSim) RunUntil(pred func(Simulator) bool) StopReason {
+	return s.runPredicate(s, pred, s.RunCycle)
+}
+
+func (s *fastSim) RunCycles(n int) StopReason {
+	return s.runCycles(n, s.RunCycle)
+}
@@ -0,0 +1,65 @@
+package mars
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newImpSim(t *testing.T, newSim func(SimulatorConfig) (Simulator, error)) Simulator {
+	config := ConfigKOTH88()
+	impdata, err := ParseLoadFile(strings.NewReader(imp88), config)
+	require.NoError(t, err)
+
+	sim, err := newSim(config)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&impdata)
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+	return sim
+}
+
+func TestRunCycles(t *testing.T) {
+	sim := newImpSim(t, NewSimulator)
+	require.Equal(t, StopCycles, sim.RunCycles(100))
+	require.Equal(t, 100, sim.CycleCount())
+	require.Equal(t, StopCycles, sim.RunCycles(50))
+	require.Equal(t, 150, sim.CycleCount())
+	require.Equal(t, StopCycleLimit, sim.RunCycles(100000))
+	require.Equal(t, 80000, sim.CycleCount())
+}
+
+func TestRunUntil(t *testing.T) {
+	sim := newImpSim(t, NewFastSimulator)
+	reason := sim.RunUntil(func(s Simulator) bool {
+		return s.GetMem(10).Op == MOV
+	})
+	require.Equal(t, StopPredicate, reason)
+	require.Equal(t, 10, sim.CycleCount())
+}
+
+func TestRunContext(t *testing.T) {
+	sim := newImpSim(t, NewFastSimulator)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, StopCancelled, sim.RunContext(ctx))
+	require.Equal(t, 1, sim.CycleCount())
+
+	require.Equal(t, StopCycleLimit, sim.RunContext(context.Background()))
+	require.Equal(t, 80000, sim.CycleCount())
+}
+
+func TestRunDecided(t *testing.T) {
+	sim, err := NewSimulator(ConfigNOP94())
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{{Op: DAT, OpMode: F}}})
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	require.Equal(t, StopDecided, sim.RunCycles(10))
+	require.Equal(t, 1, sim.CycleCount())
+	require.Equal(t, StopDecided, sim.RunUntil(func(Simulator) bool { return false }))
+	require.Equal(t, 1, sim.CycleCount())
+}
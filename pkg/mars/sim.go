@@ -1,6 +1,6 @@
 package mars
 
-import "fmt"
+import "context"
 
 type SimulatorMode uint8
 
@@ -24,10 +24,54 @@ type Simulator interface {
 	AddWarrior(data *WarriorData) (Warrior, error)
 	GetWarrior(wi int) Warrior
 	SpawnWarrior(wi int, startOffset Address) error
-	Run() []bool
+	Run() BattleResult
 	RunCycle() int
+
+	// Result returns the result of the battle in its current state
+	Result() BattleResult
+
+	// ResultInto stores the result of the battle in its current state in r,
+	// reusing the Warriors slice of r if it has enough capacity
+	ResultInto(r *BattleResult)
+
+	// RunRound resets the simulator, spawns each loaded warrior at the
+	// matching offset, runs the battle like Run and stores the result in r.
+	// Once r and the simulator's buffers have been used for a round, later
+	// rounds do not allocate memory.
+	RunRound(offsets []Address, r *BattleResult) error
+
+	// RunContext runs the simulation like Run, stopping early if ctx is
+	// cancelled. Cancellation is checked after every cycle.
+	RunContext(ctx context.Context) StopReason
+
+	// RunUntil runs the simulation like Run, stopping early after a cycle in
+	// which pred returns true
+	RunUntil(pred func(Simulator) bool) StopReason
+
+	// RunCycles runs at most n cycles of the simulation
+	RunCycles(n int) StopReason
+
 	GetMem(a Address) Instruction
 	Reset()
+
+	// Snapshot returns a copy of the complete simulator state
+	Snapshot() *Snapshot
+
+	// Restore replaces the simulator state with a Snapshot taken from a
+	// simulator with the same core size and process limit
+	Restore(snap *Snapshot) error
+
+	// EnableUndo records the changes made by the last depth cycles so they
+	// can be undone with StepBack. A depth of 0 disables recording.
+	EnableUndo(depth int)
+
+	// StepBack undoes up to n recorded cycles and returns the number of
+	// cycles undone
+	StepBack(n int) int
+
+	// Clone returns an independent copy of the simulator. Reporters are not
+	// copied to the clone.
+	Clone() Simulator
 }
 
 type ReportingSimulator interface {
@@ -36,20 +80,13 @@ type ReportingSimulator interface {
 }
 
 type reportSim struct {
-	m          Address
-	maxProcs   Address
-	maxCycles  Address
-	readLimit  Address
-	writeLimit Address
-	mem        []Instruction
-	legacy     bool
-
-	warriors     []*warrior
-	reporters    []Reporter
-	warriorIndex int
-	warriorCount int
-
-	cycleCount Address
+	simState
+	reporters []Reporter
+
+	// processes queued and operands of the current instruction, reused
+	// for each WarriorExecute report
+	queued   []Address
+	execInfo ExecInfo
 }
 
 func NewSimulator(config SimulatorConfig) (Simulator, error) {
@@ -61,31 +98,12 @@ func NewReportingSimulator(config SimulatorConfig) (ReportingSimulator, error) {
 }
 
 func newReportSim(config SimulatorConfig) (*reportSim, error) {
-	err := config.Validate()
+	state, err := newSimState(config)
 	if err != nil {
 		return nil, err
 	}
 
-	sim := &reportSim{
-		m:          Address(config.CoreSize),
-		maxProcs:   Address(config.Processes),
-		maxCycles:  Address(config.Cycles),
-		readLimit:  Address(config.ReadLimit),
-		writeLimit: Address(config.WriteLimit),
-		legacy:     config.Mode == ICWS88,
-	}
-
-	sim.mem = make([]Instruction, sim.m)
-
-	return sim, nil
-}
-
-func (s *reportSim) CoreSize() Address {
-	return s.m
-}
-
-func (s *reportSim) CycleCount() int {
-	return int(s.cycleCount)
+	return &reportSim{simState: state}, nil
 }
 
 func (s *reportSim) AddReporter(r Reporter) {
@@ -98,55 +116,16 @@ func (s *reportSim) Report(report Report) {
 	}
 }
 
-func (s *reportSim) addressSigned(a Address) int {
-	if a > (s.m / 2) {
-		return -(int(s.m) - int(a))
-	}
-	return int(a)
-}
-
-func (s *reportSim) GetWarrior(i int) Warrior {
-	if i > s.warriorCount {
-		return nil
-	}
-	return s.warriors[i]
-}
-
-func (s *reportSim) AddWarrior(data *WarriorData) (Warrior, error) {
-	return s.addWarrior(data)
-}
-
-func (s *reportSim) addWarrior(data *WarriorData) (*warrior, error) {
-	w := &warrior{
-		data: data.Copy(),
-		sim:  s,
-	}
-	w.index = len(s.warriors)
-	s.warriors = append(s.warriors, w)
-	s.warriorCount += 1
-	w.state = WarriorAdded
-
-	return w, nil
-}
-
 func (s *reportSim) SpawnWarrior(wi int, startOffset Address) error {
 	return s.spawnWarrior(wi, startOffset)
 }
 
 func (s *reportSim) spawnWarrior(wi int, startOffset Address) error {
-	if wi > s.warriorCount {
-		return fmt.Errorf("warrior index out of bounds")
-	}
-	w := s.warriors[wi]
-
-	for i := Address(0); i < Address(len(w.data.Code)); i++ {
-		s.mem[(startOffset+i)%s.m] = w.data.Code[i]
+	w, err := s.simState.spawnWarrior(wi, startOffset)
+	if err != nil {
+		return err
 	}
 
-	w.pq = newProcessQueue(s.maxProcs)
-	w.pq.Push(startOffset + Address(w.data.Start))
-	w.state = WarriorAlive
-
 	s.Report(Report{Type: WarriorSpawn, WarriorIndex: w.index, Address: startOffset})
 
 	return nil
@@ -158,276 +137,293 @@ func (s *reportSim) spawnWarrior(wi int, startOffset Address) error {
 func (s *reportSim) RunCycle() int {
 	s.Report(Report{Type: CycleStart, Cycle: int(s.cycleCount)})
 
-	var warrior *warrior
-	var pc Address
-
-	// find the first living warrior, starting at s.warriorIndex
-	// return 0 if no living warriors are found
-	for i := 0; ; i++ {
-		s.warriorIndex = (s.warriorIndex + i) % s.warriorCount
-		if s.warriors[s.warriorIndex].state == WarriorAlive {
-			warrior = s.warriors[s.warriorIndex]
-
-			// I don't like this, and this should never happen, but we will
-			// silently reap any zombie warriors here that are 'alive' without
-			// a process queue so we can continue and check the next ones.
-			var err error
-			pc, err = warrior.pq.Pop()
-			if err != nil {
-				warrior.state = WarriorDead
-				continue
-			}
+	if s.journal != nil {
+		s.journalBegin()
+	}
 
-			break
-		}
-		if i == s.warriorCount {
-			return 0
-		}
+	warrior, pc, ok := s.popTask()
+	if !ok {
+		return 0
+	}
+
+	if s.journal != nil {
+		s.journalPop(warrior)
 	}
 
 	s.Report(Report{Type: WarriorTaskPop, Cycle: int(s.cycleCount), WarriorIndex: s.warriorIndex, Address: pc})
 
+	IR := s.mem[pc]
+	dropped := warrior.pq.dropped
+	s.queued = s.queued[:0]
 	s.exec(pc, warrior)
-	if warrior.pq.Len() == 0 {
-		s.Report(Report{Type: WarriorTerminate, Cycle: int(s.cycleCount), WarriorIndex: s.warriorIndex, Address: pc})
-		warrior.state = WarriorDead
+	if len(s.reporters) > 0 {
+		s.execInfo.Queued = s.queued
+		s.Report(Report{Type: WarriorExecute, Cycle: int(s.cycleCount), WarriorIndex: s.warriorIndex, Address: pc, Instruction: IR.instruction(), Exec: &s.execInfo})
 	}
-
-	s.Report(Report{Type: CycleEnd, Cycle: int(s.cycleCount)})
-
-	s.warriorIndex = (s.warriorIndex + 1) % s.warriorCount
-	s.cycleCount++
-
-	nAlive := 0
-	for i := 0; i < s.warriorCount; i++ {
-		if s.warriors[i].state == WarriorAlive {
-			nAlive += 1
-		}
+	for ; dropped < warrior.pq.dropped; dropped++ {
+		s.Report(Report{Type: WarriorTaskDropped, Cycle: int(s.cycleCount), WarriorIndex: s.warriorIndex, Address: warrior.pq.lastDropped})
+	}
+	if s.endTask(warrior, IR) {
+		s.Report(Report{Type: WarriorTerminate, Cycle: int(s.cycleCount), WarriorIndex: s.warriorIndex, Address: pc, Cause: warrior.deathCause, Instruction: IR.instruction()})
 	}
 
-	return nAlive
-}
+	s.Report(Report{Type: CycleEnd, Cycle: int(s.cycleCount)})
 
-func (s *reportSim) readFold(pointer Address) Address {
-	res := pointer % s.readLimit
-	if res > (s.readLimit / 2) {
-		res += (s.m - s.readLimit)
+	if s.journal != nil {
+		s.journalCommit()
 	}
-	return res
-}
 
-func (s *reportSim) writeFold(pointer Address) Address {
-	res := pointer % s.writeLimit
-	if res > (s.writeLimit / 2) {
-		res += (s.m - s.writeLimit)
-	}
-	return res
+	return s.endCycle()
 }
 
 func (s *reportSim) exec(PC Address, w *warrior) {
 	IR := s.mem[PC]
 
+	if s.journal != nil {
+		s.journalOperands(PC, IR)
+	}
+
 	// read and write limit folded pointers for A, B
 	var RPA, WPA, RPB, WPB Address
 
 	// instructions referenced by A, B
-	var IRA, IRB Instruction
+	var IRA, IRB cell
 
 	// pointer to increment after IRA, IRB
 	var PIP Address
 
 	// prepare A indirect references and decrement or save increment pointer
 	if IR.AMode != IMMEDIATE {
-		RPA = s.readFold(IR.A)
-		WPA = s.writeFold(IR.A)
+		RPA = s.readFold(Address(IR.A))
+		WPA = s.writeFold(Address(IR.A))
 
 		if IR.AMode == A_INDIRECT || IR.AMode == A_DECREMENT || IR.AMode == A_INCREMENT {
 			if IR.AMode == A_DECREMENT {
 				dptr := (PC + WPA) % s.m
-				s.mem[dptr].A = (s.mem[dptr].A + s.m - 1) % s.m
-				s.Report(Report{Type: WarriorDecrement, WarriorIndex: w.index, Address: dptr})
+				s.mem[dptr].A = (s.mem[dptr].A + s.m32 - 1) % s.m32
+				s.reportAccess(WarriorDecrement, w, dptr, FieldA, OperandA)
 			}
 
 			if IR.AMode == A_INCREMENT {
 				PIP = (PC + WPA) % s.m
 			}
 
-			RPA = s.readFold(RPA + s.mem[(PC+RPA)%s.m].A)
-			// not used, but should be updated here if it were to be
-			// WPA = s.writeFold(WPA + s.mem[(PC+WPA)%s.m].A)
+			s.reportAccess(WarriorRead, w, (PC+RPA)%s.m, FieldA, OperandA)
+			RPA = s.readFold(RPA + Address(s.mem[(PC+RPA)%s.m].A))
+			// only used for the execution report
+			WPA = s.writeFold(WPA + Address(s.mem[(PC+WPA)%s.m].A))
 		}
 
 		if IR.AMode == B_INDIRECT || IR.AMode == B_DECREMENT || IR.AMode == B_INCREMENT {
 			if IR.AMode == B_DECREMENT {
 				dptr := (PC + WPA) % s.m
-				s.mem[dptr].B = (s.mem[dptr].B + s.m - 1) % s.m
-				s.Report(Report{Type: WarriorDecrement, WarriorIndex: w.index, Address: dptr})
+				s.mem[dptr].B = (s.mem[dptr].B + s.m32 - 1) % s.m32
+				s.reportAccess(WarriorDecrement, w, dptr, FieldB, OperandA)
 			}
 
 			if IR.AMode == B_INCREMENT {
 				PIP = (PC + WPA) % s.m
 			}
 
-			RPA = s.readFold(RPA + s.mem[(PC+RPA)%s.m].B)
-			// not used, but should be updated here if it were to be
-			// WPA = s.writeFold(WPA + s.mem[(PC+WPA)%s.m].B)
+			s.reportAccess(WarriorRead, w, (PC+RPA)%s.m, FieldB, OperandA)
+			RPA = s.readFold(RPA + Address(s.mem[(PC+RPA)%s.m].B))
+			// only used for the execution report
+			WPA = s.writeFold(WPA + Address(s.mem[(PC+WPA)%s.m].B))
 		}
 
 	}
 
 	// assign referenced value to IRA
 	IRA = s.mem[(PC+RPA)%s.m]
+	if IR.AMode != IMMEDIATE {
+		s.reportFields(WarriorRead, w, (PC+RPA)%s.m, operandReadFields(IR.Op, IR.OpMode, OperandA), OperandA)
+	}
 
 	// do post-increments, if needed, after IRA has been assigned
 	if IR.AMode == A_INCREMENT {
-		s.mem[PIP].A = (s.mem[PIP].A + 1) % s.m
+		s.mem[PIP].A = (s.mem[PIP].A + 1) % s.m32
+		s.reportAccess(WarriorIncrement, w, PIP, FieldA, OperandA)
 	}
 	if IR.AMode == B_INCREMENT {
-		s.mem[PIP].B = (s.mem[PIP].B + 1) % s.m
+		s.mem[PIP].B = (s.mem[PIP].B + 1) % s.m32
+		s.reportAccess(WarriorIncrement, w, PIP, FieldB, OperandA)
 	}
 
 	// prepare B indirect references and decrement or save increment pointer
 	if IR.BMode != IMMEDIATE {
-		RPB = s.readFold(IR.B)
-		WPB = s.writeFold(IR.B)
+		RPB = s.readFold(Address(IR.B))
+		WPB = s.writeFold(Address(IR.B))
 
 		if IR.BMode == A_INDIRECT || IR.BMode == A_DECREMENT || IR.BMode == A_INCREMENT {
 			if IR.BMode == A_DECREMENT {
 				dptr := (PC + WPB) % s.m
-				s.mem[dptr].A = (s.mem[dptr].A + s.m - 1) % s.m
+				s.mem[dptr].A = (s.mem[dptr].A + s.m32 - 1) % s.m32
+				s.reportAccess(WarriorDecrement, w, dptr, FieldA, OperandB)
 			}
 
 			if IR.BMode == A_INCREMENT {
 				PIP = (PC + WPB) % s.m
 			}
 
-			RPB = s.readFold(RPB + s.mem[(PC+RPB)%s.m].A)
-			WPB = s.writeFold(WPB + s.mem[(PC+WPB)%s.m].A)
+			s.reportPointerReads(w, PC, RPB, WPB, FieldA)
+			RPB = s.readFold(RPB + Address(s.mem[(PC+RPB)%s.m].A))
+			WPB = s.writeFold(WPB + Address(s.mem[(PC+WPB)%s.m].A))
 		}
 
 		if IR.BMode == B_INDIRECT || IR.BMode == B_DECREMENT || IR.BMode == B_INCREMENT {
 			if IR.BMode == B_DECREMENT {
 				dptr := (PC + WPB) % s.m
-				s.mem[dptr].B = (s.mem[dptr].B + s.m - 1) % s.m
+				s.mem[dptr].B = (s.mem[dptr].B + s.m32 - 1) % s.m32
+				s.reportAccess(WarriorDecrement, w, dptr, FieldB, OperandB)
 			}
 
 			if IR.BMode == B_INCREMENT {
 				PIP = (PC + WPB) % s.m
 			}
 
-			RPB = s.readFold(RPB + s.mem[(PC+RPB)%s.m].B)
-			WPB = s.writeFold(WPB + s.mem[(PC+WPB)%s.m].B)
+			s.reportPointerReads(w, PC, RPB, WPB, FieldB)
+			RPB = s.readFold(RPB + Address(s.mem[(PC+RPB)%s.m].B))
+			WPB = s.writeFold(WPB + Address(s.mem[(PC+WPB)%s.m].B))
 		}
 
 	}
 
 	// assign referenced value to IRB
 	IRB = s.mem[(PC+RPB)%s.m]
+	if IR.BMode != IMMEDIATE {
+		s.reportFields(WarriorRead, w, (PC+RPB)%s.m, operandReadFields(IR.Op, IR.OpMode, OperandB), OperandB)
+	}
 
 	// do post-increments, if needed, after IRB has been assigned
 	if IR.BMode == A_INCREMENT {
-		s.mem[PIP].A = (s.mem[PIP].A + 1) % s.m
-		s.Report(Report{Type: WarriorIncrement, WarriorIndex: w.index, Address: PIP})
+		s.mem[PIP].A = (s.mem[PIP].A + 1) % s.m32
+		s.reportAccess(WarriorIncrement, w, PIP, FieldA, OperandB)
 	} else if IR.BMode == B_INCREMENT {
-		s.mem[PIP].B = (s.mem[PIP].B + 1) % s.m
-		s.Report(Report{Type: WarriorIncrement, WarriorIndex: w.index, Address: PIP})
+		s.mem[PIP].B = (s.mem[PIP].B + 1) % s.m32
+		s.reportAccess(WarriorIncrement, w, PIP, FieldB, OperandB)
 	}
 
 	WAB := (PC + WPB) % s.m
 	RAB := (PC + RPA) % s.m
 
+	s.execInfo = ExecInfo{
+		RPA: RAB, WPA: (PC + WPA) % s.m,
+		RPB: (PC + RPB) % s.m, WPB: WAB,
+		IRA: IRA.instruction(), IRB: IRB.instruction(),
+	}
+
+	if s.journal != nil {
+		s.journalCell(WAB)
+	}
+
 	switch IR.Op {
 	case DAT:
-		s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+		s.reportTerminate(w, PC, IR, CauseDAT)
 		return
 	case MOV:
 		s.mov(IR, IRA, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		if IR.OpMode == I {
+			s.reportAccess(WarriorWrite, w, WAB, FieldInstruction, OperandB)
+		} else if IR.OpMode < I {
+			s.reportFields(WarriorWrite, w, WAB, targetFields[IR.OpMode], OperandB)
+		}
 	case ADD:
 		s.add(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		s.reportFields(WarriorWrite, w, WAB, operandReadFields(IR.Op, IR.OpMode, OperandB), OperandB)
 	case SUB:
 		s.sub(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		s.reportFields(WarriorWrite, w, WAB, operandReadFields(IR.Op, IR.OpMode, OperandB), OperandB)
 	case MUL:
 		s.mul(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		s.reportFields(WarriorWrite, w, WAB, operandReadFields(IR.Op, IR.OpMode, OperandB), OperandB)
 	case DIV:
 		s.div(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		s.reportDivWrites(w, IR, IRA, WAB)
 	case MOD:
 		s.mod(IR, IRA, IRB, WAB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: WAB})
+		s.reportDivWrites(w, IR, IRA, WAB)
 	case JMP:
-		w.pq.Push(RAB)
+		s.push(w, RAB)
 	case JMZ:
 		s.jmz(IR, IRB, RAB, PC, w)
 	case JMN:
 		s.jmn(IR, IRB, RAB, PC, w)
 	case DJN:
 		s.djn(IR, IRB, RAB, WAB, PC, w)
-		s.Report(Report{Type: WarriorDecrement, WarriorIndex: w.index, Address: WAB})
+		if IR.OpMode <= I {
+			s.reportFields(WarriorDecrement, w, WAB, targetFields[IR.OpMode], OperandB)
+		}
 	case CMP:
 		fallthrough
 	case SEQ:
 		s.cmp(IR, IRA, IRB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: (PC + RPA) % s.m})
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: (PC + RPB) % s.m})
 	case SLT:
 		s.slt(IR, IRA, IRB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: (PC + RPA) % s.m})
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: (PC + RPB) % s.m})
 	case SNE:
 		s.sne(IR, IRA, IRB, PC, w)
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: (PC + RPA) % s.m})
-		s.Report(Report{Type: WarriorWrite, WarriorIndex: w.index, Address: (PC + RPB) % s.m})
 	case SPL:
-		w.pq.Push((PC + 1) % s.m)
-		w.pq.Push(RAB)
+		s.push(w, (PC+1)%s.m)
+		s.push(w, RAB)
 	case NOP:
-		w.pq.Push((PC + 1) % s.m)
+		s.push(w, (PC+1)%s.m)
+	default:
+		if !s.execExtension(s, w, PC, RAB, WAB, IR, IRA, IRB) {
+			s.reportTerminate(w, PC, IR, CauseIllegal)
+		} else if len(s.queued) == 0 {
+			s.reportTerminate(w, PC, IR, CauseExtension)
+		}
 	}
 }
 
+// reportPointerReads reports the reads of a B operand's indirect pointer,
+// which is read through both the read and write limits
+func (s *reportSim) reportPointerReads(w *warrior, PC, RPB, WPB Address, field Field) {
+	s.reportAccess(WarriorRead, w, (PC+RPB)%s.m, field, OperandB)
+	if (PC+WPB)%s.m != (PC+RPB)%s.m {
+		s.reportAccess(WarriorRead, w, (PC+WPB)%s.m, field, OperandB)
+	}
+}
+
+// reportTerminate reports a task of w terminated by executing IR at PC
+func (s *reportSim) reportTerminate(w *warrior, PC Address, IR cell, cause TerminateCause) {
+	s.Report(Report{Type: WarriorTaskTerminate, Cycle: int(s.cycleCount), WarriorIndex: w.index, Address: PC, Cause: cause, Instruction: IR.instruction()})
+}
+
 // Run runs the simulator until the max cycles are reached, one warrior
 // remains in a battle with more than one warrior, or the only warrior
 // dies in a single warrior battle
-func (s *reportSim) Run() []bool {
-	nWarriors := len(s.warriors)
+func (s *reportSim) Run() BattleResult {
+	return s.run(s.RunCycle)
+}
 
-	// if no warriors are loaded, return nil
-	if nWarriors == 0 {
-		return nil
+func (s *reportSim) Restore(snap *Snapshot) error {
+	err := s.restore(snap)
+	if err != nil {
+		return err
 	}
+	s.Report(Report{Type: SimRestore, Cycle: int(s.cycleCount)})
+	return nil
+}
 
-	// run until simulation
-	for s.cycleCount < s.maxCycles {
-		aliveCount := s.RunCycle()
-
-		if nWarriors == 1 && aliveCount == 0 {
-			break
-		} else if nWarriors > 1 && aliveCount == 1 {
-			break
-		}
+func (s *reportSim) StepBack(n int) int {
+	undone := s.simState.StepBack(n)
+	if undone > 0 {
+		s.Report(Report{Type: SimRestore, Cycle: int(s.cycleCount)})
 	}
+	return undone
+}
 
-	// collect and return results
-	result := make([]bool, nWarriors)
-	for i, warrior := range s.warriors {
-		result[i] = warrior.Alive()
-	}
-	return result
+func (s *reportSim) Clone() Simulator {
+	c := &reportSim{}
+	s.cloneInto(&c.simState)
+	return c
 }
 
-func (s *reportSim) GetMem(a Address) Instruction {
-	return s.mem[a%s.m]
+func (s *reportSim) RunRound(offsets []Address, r *BattleResult) error {
+	return s.runRound(s.Reset, s.spawnWarrior, s.RunCycle, offsets, r)
 }
 
 func (s *reportSim) Reset() {
 	s.Report(Report{Type: SimReset})
-
-	for _, warrior := range s.warriors {
-		warrior.state = WarriorAdded
-	}
-	s.mem = make([]Instruction, s.m)
+	s.reset()
 }
@@ -58,9 +58,12 @@ func TestRunImp(t *testing.T) {
 	err = sim.SpawnWarrior(0, 0)
 	require.NoError(t, err)
 
-	state := sim.Run()
-	require.Equal(t, 1, len(state))
-	require.True(t, state[0])
+	result := sim.Run()
+	require.Equal(t, 1, len(result.Warriors))
+	require.True(t, result.Warriors[0].Alive)
+	require.Equal(t, TerminationCycleLimit, result.Reason)
+	require.Equal(t, -1, result.Winner)
+	require.Equal(t, 80000, result.Cycles)
 	require.True(t, w.Alive())
 	require.Equal(t, 80000, sim.CycleCount())
 }
@@ -82,11 +85,65 @@ func TestRunTwoImps(t *testing.T) {
 	err = sim.SpawnWarrior(1, 200)
 	require.NoError(t, err)
 
-	state := sim.Run()
-	require.Equal(t, 2, len(state))
-	require.True(t, state[0])
-	require.True(t, state[1])
+	result := sim.Run()
+	require.Equal(t, []bool{true, true}, result.Survivors())
+	require.True(t, result.Tie)
 	require.True(t, w.Alive())
 	require.True(t, w2.Alive())
 	require.Equal(t, 80000, sim.CycleCount())
 }
+
+type execRecorder []ExecInfo
+
+func (r *execRecorder) Report(report Report) {
+	if report.Type != WarriorExecute {
+		return
+	}
+	info := *report.Exec
+	info.Queued = append([]Address(nil), info.Queued...)
+	*r = append(*r, info)
+}
+
+func TestExecuteReport(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94())
+	require.NoError(t, err)
+	rec := &execRecorder{}
+	sim.AddReporter(rec)
+	_, err = sim.AddWarrior(makeDwarfData())
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	sim.RunCycle()
+	sim.RunCycle()
+	sim.RunCycle()
+
+	dat := func(b Address) Instruction {
+		return Instruction{Op: DAT, OpMode: F, AMode: IMMEDIATE, BMode: IMMEDIATE, B: b}
+	}
+	add := sim.GetMem(0)
+	require.Equal(t, []ExecInfo{
+		{RPA: 0, WPA: 0, RPB: 3, WPB: 3, IRA: add, IRB: dat(0), Queued: []Address{1}},
+		{RPA: 3, WPA: 3, RPB: 7, WPB: 7, IRA: dat(4), IRB: Instruction{}, Queued: []Address{2}},
+		{RPA: 0, WPA: 0, RPB: 2, WPB: 2, IRA: add, IRB: sim.GetMem(2), Queued: []Address{0}},
+	}, []ExecInfo(*rec))
+}
+
+func TestExecuteReportTerminate(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94())
+	require.NoError(t, err)
+	rec := &execRecorder{}
+	sim.AddReporter(rec)
+	_, err = sim.AddWarrior(&WarriorData{Code: []Instruction{
+		{Op: SPL, OpMode: B, AMode: DIRECT, A: 2, BMode: DIRECT, B: 0},
+		{Op: DAT, OpMode: F, AMode: IMMEDIATE, BMode: IMMEDIATE},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	sim.RunCycle()
+	sim.RunCycle()
+
+	require.Len(t, *rec, 2)
+	require.Equal(t, []Address{1, 2}, (*rec)[0].Queued)
+	require.Empty(t, (*rec)[1].Queued)
+}
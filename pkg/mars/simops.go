@@ -1,6 +1,13 @@
 package mars
 
-func (s *reportSim) mov(IR, IRA Instruction, WAB, PC Address, w *warrior) {
+// push adds a process to the queue of w and records it for the execution
+// report
+func (s *reportSim) push(w *warrior, a Address) {
+	s.queued = append(s.queued, a)
+	w.pq.Push(a)
+}
+
+func (s *reportSim) mov(IR, IRA cell, WAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
 		s.mem[WAB].A = IRA.A
@@ -19,103 +26,103 @@ func (s *reportSim) mov(IR, IRA Instruction, WAB, PC Address, w *warrior) {
 	case I:
 		s.mem[WAB] = IRA
 	}
-	w.pq.Push((PC + 1) % s.m)
+	s.push(w, (PC+1)%s.m)
 }
 
-func (s *reportSim) add(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
+func (s *reportSim) add(IR, IRA, IRB cell, WAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
-		s.mem[WAB].A = (IRB.A + IRA.A) % s.m
+		s.mem[WAB].A = (IRB.A + IRA.A) % s.m32
 	case B:
-		s.mem[WAB].B = (IRB.B + IRA.B) % s.m
+		s.mem[WAB].B = (IRB.B + IRA.B) % s.m32
 	case AB:
-		s.mem[WAB].B = (IRB.B + IRA.A) % s.m
+		s.mem[WAB].B = (IRB.B + IRA.A) % s.m32
 	case BA:
-		s.mem[WAB].A = (IRB.A + IRA.B) % s.m
+		s.mem[WAB].A = (IRB.A + IRA.B) % s.m32
 	case I:
 		fallthrough
 	case F:
-		s.mem[WAB].A = (IRB.A + IRA.A) % s.m
-		s.mem[WAB].B = (IRB.B + IRA.B) % s.m
+		s.mem[WAB].A = (IRB.A + IRA.A) % s.m32
+		s.mem[WAB].B = (IRB.B + IRA.B) % s.m32
 	case X:
-		s.mem[WAB].A = (IRB.A + IRA.B) % s.m
-		s.mem[WAB].B = (IRB.B + IRA.A) % s.m
+		s.mem[WAB].A = (IRB.A + IRA.B) % s.m32
+		s.mem[WAB].B = (IRB.B + IRA.A) % s.m32
 	}
-	w.pq.Push((PC + 1) % s.m)
+	s.push(w, (PC+1)%s.m)
 }
 
-func (s *reportSim) sub(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
+func (s *reportSim) sub(IR, IRA, IRB cell, WAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
-		s.mem[WAB].A = (IRB.A + (s.m - IRA.A)) % s.m
+		s.mem[WAB].A = (IRB.A + (s.m32 - IRA.A)) % s.m32
 	case B:
-		s.mem[WAB].B = (IRB.B + (s.m - IRA.B)) % s.m
+		s.mem[WAB].B = (IRB.B + (s.m32 - IRA.B)) % s.m32
 	case AB:
-		s.mem[WAB].B = (IRB.B + (s.m - IRA.A)) % s.m
+		s.mem[WAB].B = (IRB.B + (s.m32 - IRA.A)) % s.m32
 	case BA:
-		s.mem[WAB].A = (IRB.A + (s.m - IRA.B)) % s.m
+		s.mem[WAB].A = (IRB.A + (s.m32 - IRA.B)) % s.m32
 	case I:
 		fallthrough
 	case F:
-		s.mem[WAB].A = (IRB.A + (s.m - IRA.A)) % s.m
-		s.mem[WAB].B = (IRB.B + (s.m - IRA.B)) % s.m
+		s.mem[WAB].A = (IRB.A + (s.m32 - IRA.A)) % s.m32
+		s.mem[WAB].B = (IRB.B + (s.m32 - IRA.B)) % s.m32
 	case X:
-		s.mem[WAB].A = (IRB.A + (s.m - IRA.B)) % s.m
-		s.mem[WAB].B = (IRB.B + (s.m - IRA.A)) % s.m
+		s.mem[WAB].A = (IRB.A + (s.m32 - IRA.B)) % s.m32
+		s.mem[WAB].B = (IRB.B + (s.m32 - IRA.A)) % s.m32
 	}
-	w.pq.Push((PC + 1) % s.m)
+	s.push(w, (PC+1)%s.m)
 }
 
-func (s *reportSim) mul(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
+func (s *reportSim) mul(IR, IRA, IRB cell, WAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
-		s.mem[WAB].A = (IRB.A * IRA.A) % s.m
+		s.mem[WAB].A = s.mulm(IRB.A, IRA.A)
 	case B:
-		s.mem[WAB].B = (IRB.B * IRA.B) % s.m
+		s.mem[WAB].B = s.mulm(IRB.B, IRA.B)
 	case AB:
-		s.mem[WAB].B = (IRB.B * IRA.A) % s.m
+		s.mem[WAB].B = s.mulm(IRB.B, IRA.A)
 	case BA:
-		s.mem[WAB].A = (IRB.A * IRA.B) % s.m
+		s.mem[WAB].A = s.mulm(IRB.A, IRA.B)
 	case I:
 		fallthrough
 	case F:
-		s.mem[WAB].A = (IRB.A * IRA.A) % s.m
-		s.mem[WAB].B = (IRB.B * IRA.B) % s.m
+		s.mem[WAB].A = s.mulm(IRB.A, IRA.A)
+		s.mem[WAB].B = s.mulm(IRB.B, IRA.B)
 	case X:
-		s.mem[WAB].A = (IRB.A * IRA.B) % s.m
-		s.mem[WAB].B = (IRB.B * IRA.A) % s.m
+		s.mem[WAB].A = s.mulm(IRB.A, IRA.B)
+		s.mem[WAB].B = s.mulm(IRB.B, IRA.A)
 	}
-	w.pq.Push((PC + 1) % s.m)
+	s.push(w, (PC+1)%s.m)
 }
 
-func (s *reportSim) div(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
+func (s *reportSim) div(IR, IRA, IRB cell, WAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
 		if IRA.A != 0 {
 			s.mem[WAB].A = IRB.A / IRA.A
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseDivZero)
 			return
 		}
 	case B:
 		if IRA.B != 0 {
 			s.mem[WAB].B = IRB.B / IRA.B
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseDivZero)
 			return
 		}
 	case AB:
 		if IRA.A != 0 {
 			s.mem[WAB].B = IRB.B / IRA.A
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseDivZero)
 			return
 		}
 	case BA:
 		if IRA.B != 0 {
 			s.mem[WAB].A = IRB.A / IRA.B
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseDivZero)
 			return
 		}
 	case F:
@@ -128,7 +135,7 @@ func (s *reportSim) div(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 			s.mem[WAB].B = IRB.B / IRA.B
 		}
 		if IRA.A == 0 || IRA.B == 0 {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseDivZero)
 			return
 		}
 	case X:
@@ -139,41 +146,41 @@ func (s *reportSim) div(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 			s.mem[WAB].A = IRB.A / IRA.B
 		}
 		if IRA.A == 0 || IRA.B == 0 {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseDivZero)
 			return
 		}
 	}
-	w.pq.Push((PC + 1) % s.m)
+	s.push(w, (PC+1)%s.m)
 }
 
-func (s *reportSim) mod(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
+func (s *reportSim) mod(IR, IRA, IRB cell, WAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
 		if IRA.A != 0 {
 			s.mem[WAB].A = IRB.A % IRA.A
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseModZero)
 			return
 		}
 	case B:
 		if IRA.B != 0 {
 			s.mem[WAB].B = IRB.B % IRA.B
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseModZero)
 			return
 		}
 	case AB:
 		if IRA.A != 0 {
 			s.mem[WAB].B = IRB.B % IRA.A
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseModZero)
 			return
 		}
 	case BA:
 		if IRA.B != 0 {
 			s.mem[WAB].A = IRB.A % IRA.B
 		} else {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseModZero)
 			return
 		}
 	case F:
@@ -186,7 +193,7 @@ func (s *reportSim) mod(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 			s.mem[WAB].B = IRB.B % IRA.B
 		}
 		if IRA.A == 0 || IRA.B == 0 {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseModZero)
 			return
 		}
 	case X:
@@ -197,30 +204,30 @@ func (s *reportSim) mod(IR, IRA, IRB Instruction, WAB, PC Address, w *warrior) {
 			s.mem[WAB].A = IRB.A % IRA.B
 		}
 		if IRA.A == 0 || IRA.B == 0 {
-			s.Report(Report{Type: WarriorTaskTerminate, WarriorIndex: w.index, Address: PC})
+			s.reportTerminate(w, PC, IR, CauseModZero)
 			return
 		}
 	}
-	w.pq.Push((PC + 1) % s.m)
+	s.push(w, (PC+1)%s.m)
 }
 
-func (s *reportSim) jmz(IR, IRB Instruction, RAB, PC Address, w *warrior) {
+func (s *reportSim) jmz(IR, IRB cell, RAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
 		fallthrough
 	case BA:
 		if IRB.A == 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case B:
 		fallthrough
 	case AB:
 		if IRB.B == 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case F:
 		fallthrough
@@ -228,30 +235,32 @@ func (s *reportSim) jmz(IR, IRB Instruction, RAB, PC Address, w *warrior) {
 		fallthrough
 	case I:
 		if IRB.A == 0 && IRB.B == 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
+	default:
+		s.reportTerminate(w, PC, IR, CauseIllegal)
 	}
 }
 
-func (s *reportSim) jmn(IR, IRB Instruction, RAB, PC Address, w *warrior) {
+func (s *reportSim) jmn(IR, IRB cell, RAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
 		fallthrough
 	case BA:
 		if IRB.A != 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case B:
 		fallthrough
 	case AB:
 		if IRB.B != 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case F:
 		fallthrough
@@ -259,190 +268,200 @@ func (s *reportSim) jmn(IR, IRB Instruction, RAB, PC Address, w *warrior) {
 		fallthrough
 	case I:
 		if IRB.A != 0 || IRB.B != 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
+	default:
+		s.reportTerminate(w, PC, IR, CauseIllegal)
 	}
 }
 
-func (s *reportSim) djn(IR, IRB Instruction, RAB, WAB, PC Address, w *warrior) {
+func (s *reportSim) djn(IR, IRB cell, RAB, WAB, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
 		fallthrough
 	case BA:
-		s.mem[WAB].A = (s.mem[WAB].A + s.m - 1) % s.m
+		s.mem[WAB].A = (s.mem[WAB].A + s.m32 - 1) % s.m32
 		IRB.A -= 1
 		if IRB.A != 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case B:
 		fallthrough
 	case AB:
-		s.mem[WAB].B = (s.mem[WAB].B + s.m - 1) % s.m
+		s.mem[WAB].B = (s.mem[WAB].B + s.m32 - 1) % s.m32
 		IRB.B -= 1
 		if IRB.B != 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case F:
 		fallthrough
 	case X:
 		fallthrough
 	case I:
-		s.mem[WAB].A = (s.mem[WAB].A + s.m - 1) % s.m
+		s.mem[WAB].A = (s.mem[WAB].A + s.m32 - 1) % s.m32
 		IRB.A -= 1
-		s.mem[WAB].B = (s.mem[WAB].B + s.m - 1) % s.m
+		s.mem[WAB].B = (s.mem[WAB].B + s.m32 - 1) % s.m32
 		IRB.B -= 1
 		if IRB.B != 0 || IRB.A != 0 {
-			w.pq.Push(RAB)
+			s.push(w, RAB)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
+	default:
+		s.reportTerminate(w, PC, IR, CauseIllegal)
 	}
 }
 
-func (s *reportSim) cmp(IR, IRA, IRB Instruction, PC Address, w *warrior) {
+func (s *reportSim) cmp(IR, IRA, IRB cell, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
 		if IRA.A == IRB.A {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case B:
 		if IRA.B == IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case AB:
 		if IRA.A == IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case BA:
 		if IRA.B == IRB.A {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case F:
 		if IRA.A == IRB.A && IRA.B == IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case X:
 		if IRA.A == IRB.B && IRA.B == IRB.A {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case I:
 		if IRA.Op == IRB.Op && IRA.OpMode == IRB.OpMode &&
 			IRA.AMode == IRB.AMode && IRA.A == IRB.A &&
 			IRA.BMode == IRB.BMode && IRA.B == IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
+	default:
+		s.reportTerminate(w, PC, IR, CauseIllegal)
 	}
 }
 
-func (s *reportSim) sne(IR, IRA, IRB Instruction, PC Address, w *warrior) {
+func (s *reportSim) sne(IR, IRA, IRB cell, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
 		if IRA.A != IRB.A {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case B:
 		if IRA.B != IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case AB:
 		if IRA.A != IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case BA:
 		if IRA.B != IRB.A {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case F:
 		if IRA.A != IRB.A || IRA.B != IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case X:
 		if IRA.A != IRB.B || IRA.B != IRB.A {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case I:
 		if IRA.Op != IRB.Op || IRA.OpMode != IRB.OpMode ||
 			IRA.AMode != IRB.AMode || IRA.A != IRB.A ||
 			IRA.BMode != IRB.BMode || IRA.B != IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
+	default:
+		s.reportTerminate(w, PC, IR, CauseIllegal)
 	}
 }
 
-func (s *reportSim) slt(IR, IRA, IRB Instruction, PC Address, w *warrior) {
+func (s *reportSim) slt(IR, IRA, IRB cell, PC Address, w *warrior) {
 	switch IR.OpMode {
 	case A:
 		if IRA.A < IRB.A {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case B:
 		if IRA.B < IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case AB:
 		if IRA.A < IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case BA:
 		if IRA.B < IRB.A {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 	case F:
 		fallthrough
 	case I:
 		if IRA.A < IRB.A && IRA.B < IRB.B {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
 
 	case X:
 		if IRA.A < IRB.B && IRA.B < IRB.A {
-			w.pq.Push((PC + 2) % s.m)
+			s.push(w, (PC+2)%s.m)
 		} else {
-			w.pq.Push((PC + 1) % s.m)
+			s.push(w, (PC+1)%s.m)
 		}
+	default:
+		s.reportTerminate(w, PC, IR, CauseIllegal)
 	}
 }
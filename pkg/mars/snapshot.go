@@ -0,0 +1,396 @@
+package mars
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// SnapshotVersion is the current version of the Snapshot binary encoding.
+// Snapshots encoded with this or any earlier version can be decoded.
+//
+// Version 2 added the DeathCycle and PeakProcesses warrior fields, and
+// version 3 added the QueuePolicy config field and Dropped warrior field,
+// version 4 added the DeathCause warrior field, version 5 added the Opcodes
+// config field, and version 6 added the core fill config fields.
+const SnapshotVersion = 6
+
+var snapshotMagic = [4]byte{'G', 'M', 'S', 'S'}
+
+// Snapshot holds the complete state of a Simulator: the configuration, core
+// memory, warriors, process queues, cycle count and the index of the warrior
+// to execute next.
+type Snapshot struct {
+	Config       SimulatorConfig
+	Cycle        Address
+	WarriorIndex int
+	Memory       []Instruction
+	Warriors     []WarriorSnapshot
+}
+
+// WarriorSnapshot holds the state of a single warrior in a Snapshot
+type WarriorSnapshot struct {
+	Data  WarriorData
+	State WarriorState
+	Queue []Address
+	// DeathCycle is the cycle the warrior died on, or -1
+	DeathCycle    int
+	DeathCause    TerminateCause
+	PeakProcesses Address
+	// Dropped is the number of processes discarded from a full queue
+	Dropped Address
+	// PSpace is reserved for P-space contents and is currently always empty
+	PSpace []Address
+}
+
+func (s *simState) snapshot() *Snapshot {
+	mem := make([]Instruction, len(s.mem))
+	for i, c := range s.mem {
+		mem[i] = c.instruction()
+	}
+
+	warriors := make([]WarriorSnapshot, len(s.warriors))
+	for i, w := range s.warriors {
+		warriors[i] = WarriorSnapshot{
+			Data:          *w.data.Copy(),
+			State:         w.state,
+			Queue:         w.Queue(),
+			DeathCycle:    w.deathCycle,
+			DeathCause:    w.deathCause,
+			PeakProcesses: w.peakProcs,
+			Dropped:       w.dropped(),
+			PSpace:        []Address{},
+		}
+	}
+
+	return &Snapshot{
+		Config:       s.config,
+		Cycle:        s.cycleCount,
+		WarriorIndex: s.warriorIndex,
+		Memory:       mem,
+		Warriors:     warriors,
+	}
+}
+
+// restore replaces the state of the simulator with the contents of snap. The
+// core size and process limit of the snapshot must match the simulator.
+func (s *simState) restore(snap *Snapshot) error {
+	if snap.Config.CoreSize != s.m || Address(len(snap.Memory)) != s.m {
+		return fmt.Errorf("snapshot core size %d does not match simulator core size %d", snap.Config.CoreSize, s.m)
+	}
+	if snap.Config.Processes != s.maxProcs {
+		return fmt.Errorf("snapshot process limit %d does not match simulator process limit %d", snap.Config.Processes, s.maxProcs)
+	}
+	if len(snap.Warriors) > 0 && (snap.WarriorIndex < 0 || snap.WarriorIndex >= len(snap.Warriors)) {
+		return fmt.Errorf("snapshot warrior index out of bounds")
+	}
+
+	for i, ws := range snap.Warriors {
+		if Address(len(ws.Queue)) > s.maxProcs {
+			return fmt.Errorf("warrior %d: process queue exceeds process limit", i)
+		}
+	}
+
+	// existing warriors are updated in place so that Warrior values returned
+	// by AddWarrior and GetWarrior remain valid
+	warriors := make([]*warrior, len(snap.Warriors))
+	for i, ws := range snap.Warriors {
+		w := &warrior{sim: s, index: i}
+		if i < len(s.warriors) {
+			w = s.warriors[i]
+		}
+		w.data = ws.Data.Copy()
+		w.state = ws.State
+		w.deathCycle = ws.DeathCycle
+		w.deathCause = ws.DeathCause
+		w.peakProcs = ws.PeakProcesses
+		w.pq = nil
+		if ws.State != WarriorAdded {
+			w.pq = newProcessQueue(s.maxProcs, s.config.QueuePolicy)
+			for _, pc := range ws.Queue {
+				w.pq.Push(pc % s.m)
+			}
+			w.pq.dropped = ws.Dropped
+		}
+		warriors[i] = w
+	}
+
+	if s.journal != nil {
+		s.journal.clear()
+	}
+
+	for i, inst := range snap.Memory {
+		inst.A %= s.m
+		inst.B %= s.m
+		s.mem[i] = newCell(inst)
+	}
+	s.warriors = warriors
+	s.warriorCount = len(warriors)
+	s.warriorIndex = snap.WarriorIndex
+	s.cycleCount = snap.Cycle
+
+	return nil
+}
+
+// cloneInto copies the state of s into c, which must be the zero value of
+// the embedding simulator's state
+func (s *simState) cloneInto(c *simState) {
+	*c = *s
+	c.journal = nil
+	c.mem = make([]cell, len(s.mem))
+	copy(c.mem, s.mem)
+
+	c.warriors = make([]*warrior, len(s.warriors))
+	for i, w := range s.warriors {
+		cw := *w
+		cw.sim = c
+		if w.pq != nil {
+			pq := *w.pq
+			pq.queue = make([]Address, len(w.pq.queue))
+			copy(pq.queue, w.pq.queue)
+			cw.pq = &pq
+		}
+		c.warriors[i] = &cw
+	}
+}
+
+// MarshalBinary encodes the snapshot in a versioned binary format
+func (snap *Snapshot) MarshalBinary() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.Write(snapshotMagic[:])
+
+	e := snapshotEncoder{buf: buf}
+	e.uint(SnapshotVersion)
+
+	e.config(snap.Config)
+
+	e.uint(uint64(snap.Cycle))
+	e.uint(uint64(snap.WarriorIndex))
+
+	e.uint(uint64(len(snap.Memory)))
+	for _, inst := range snap.Memory {
+		e.instruction(inst)
+	}
+
+	e.uint(uint64(len(snap.Warriors)))
+	for _, w := range snap.Warriors {
+		e.string(w.Data.Name)
+		e.string(w.Data.Author)
+		e.string(w.Data.Strategy)
+		e.uint(uint64(w.Data.Start))
+		e.uint(uint64(len(w.Data.Code)))
+		for _, inst := range w.Data.Code {
+			e.instruction(inst)
+		}
+		e.uint(uint64(w.State))
+		e.addresses(w.Queue)
+		e.uint(uint64(w.DeathCycle + 1))
+		e.uint(uint64(w.PeakProcesses))
+		e.uint(uint64(w.Dropped))
+		e.uint(uint64(w.DeathCause))
+		e.addresses(w.PSpace)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a snapshot encoded by MarshalBinary
+func (snap *Snapshot) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil || magic != snapshotMagic {
+		return fmt.Errorf("invalid snapshot header")
+	}
+
+	d := snapshotDecoder{r: r}
+	version := d.uint()
+	if d.err == nil && (version == 0 || version > SnapshotVersion) {
+		return fmt.Errorf("unsupported snapshot version %d", version)
+	}
+
+	out := Snapshot{}
+	out.Config.Mode = SimulatorMode(d.uint())
+	out.Config.CoreSize = Address(d.uint())
+	out.Config.Processes = Address(d.uint())
+	out.Config.Cycles = Address(d.uint())
+	out.Config.ReadLimit = Address(d.uint())
+	out.Config.WriteLimit = Address(d.uint())
+	out.Config.Length = Address(d.uint())
+	out.Config.Distance = Address(d.uint())
+	if version >= 3 {
+		out.Config.QueuePolicy = QueuePolicy(d.uint())
+	}
+	if version >= 5 {
+		n := d.length()
+		for i := 0; i < n; i++ {
+			out.Config.Opcodes = append(out.Config.Opcodes, d.string())
+		}
+	}
+	if version >= 6 {
+		out.Config.CoreFill = CoreFill(d.uint())
+		out.Config.FillInstruction = d.instruction()
+		out.Config.FillSeed = int64(d.uint())
+		out.Config.CoreImage = d.string()
+	}
+
+	out.Cycle = Address(d.uint())
+	out.WarriorIndex = int(d.uint())
+
+	out.Memory = make([]Instruction, d.length())
+	for i := range out.Memory {
+		out.Memory[i] = d.instruction()
+	}
+
+	out.Warriors = make([]WarriorSnapshot, d.length())
+	for i := range out.Warriors {
+		w := &out.Warriors[i]
+		w.Data.Name = d.string()
+		w.Data.Author = d.string()
+		w.Data.Strategy = d.string()
+		w.Data.Start = int(d.uint())
+		w.Data.Code = make([]Instruction, d.length())
+		for j := range w.Data.Code {
+			w.Data.Code[j] = d.instruction()
+		}
+		w.State = WarriorState(d.uint())
+		w.Queue = d.addresses()
+		if version >= 2 {
+			w.DeathCycle = int(d.uint()) - 1
+			w.PeakProcesses = Address(d.uint())
+		}
+		if version >= 3 {
+			w.Dropped = Address(d.uint())
+		}
+		if version >= 4 {
+			w.DeathCause = TerminateCause(d.uint())
+		}
+		if version < 2 {
+			w.DeathCycle = -1
+			w.PeakProcesses = Address(len(w.Queue))
+		}
+		w.PSpace = d.addresses()
+	}
+
+	if d.err != nil {
+		return fmt.Errorf("error decoding snapshot: %s", d.err)
+	}
+
+	*snap = out
+	return nil
+}
+
+type snapshotEncoder struct {
+	buf *bytes.Buffer
+}
+
+func (e *snapshotEncoder) uint(v uint64) {
+	e.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (e *snapshotEncoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *snapshotEncoder) instruction(inst Instruction) {
+	e.buf.Write([]byte{byte(inst.Op), byte(inst.OpMode), byte(inst.AMode), byte(inst.BMode)})
+	e.uint(uint64(inst.A))
+	e.uint(uint64(inst.B))
+}
+
+func (e *snapshotEncoder) config(c SimulatorConfig) {
+	e.uint(uint64(c.Mode))
+	e.uint(uint64(c.CoreSize))
+	e.uint(uint64(c.Processes))
+	e.uint(uint64(c.Cycles))
+	e.uint(uint64(c.ReadLimit))
+	e.uint(uint64(c.WriteLimit))
+	e.uint(uint64(c.Length))
+	e.uint(uint64(c.Distance))
+	e.uint(uint64(c.QueuePolicy))
+	e.uint(uint64(len(c.Opcodes)))
+	for _, name := range c.Opcodes {
+		e.string(name)
+	}
+	e.uint(uint64(c.CoreFill))
+	e.instruction(c.FillInstruction)
+	e.uint(uint64(c.FillSeed))
+	e.string(c.CoreImage)
+}
+
+func (e *snapshotEncoder) addresses(a []Address) {
+	e.uint(uint64(len(a)))
+	for _, v := range a {
+		e.uint(uint64(v))
+	}
+}
+
+// snapshotDecoder reads values until the first error, which is saved in err
+type snapshotDecoder struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (d *snapshotDecoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.err = err
+	}
+	return v
+}
+
+// length reads a slice length and checks it against the remaining input
+func (d *snapshotDecoder) length() int {
+	n := d.uint()
+	if n > uint64(d.r.Len()) {
+		if d.err == nil {
+			d.err = fmt.Errorf("invalid length %d", n)
+		}
+		return 0
+	}
+	return int(n)
+}
+
+func (d *snapshotDecoder) string() string {
+	n := d.length()
+	if d.err != nil {
+		return ""
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		d.err = err
+	}
+	return string(buf)
+}
+
+func (d *snapshotDecoder) instruction() Instruction {
+	if d.err != nil {
+		return Instruction{}
+	}
+	var b [4]byte
+	if _, err := io.ReadFull(d.r, b[:]); err != nil {
+		d.err = err
+		return Instruction{}
+	}
+	return Instruction{
+		Op:     OpCode(b[0]),
+		OpMode: OpMode(b[1]),
+		AMode:  AddressMode(b[2]),
+		BMode:  AddressMode(b[3]),
+		A:      Address(d.uint()),
+		B:      Address(d.uint()),
+	}
+}
+
+func (d *snapshotDecoder) addresses() []Address {
+	out := make([]Address, d.length())
+	for i := range out {
+		out[i] = Address(d.uint())
+	}
+	return out
+}
@@ -0,0 +1,243 @@
+package mars
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newSplitImpBattle returns a simulator with a dwarf and a warrior that fills
+// its process queue with imps, spawned at an offset chosen from seed
+func newSplitImpBattle(t *testing.T, newSim func(SimulatorConfig) (Simulator, error), seed int64) Simulator {
+	r := rand.New(rand.NewSource(seed))
+	config := NewQuickConfig(ICWS94, 200, 64, 2000, 20)
+
+	sim, err := newSim(config)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(makeDwarfData())
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	splitImp := &WarriorData{Code: []Instruction{
+		{Op: SPL, OpMode: B, AMode: DIRECT, A: 0, BMode: DIRECT, B: 0},
+		{Op: MOV, OpMode: I, AMode: DIRECT, A: 0, BMode: DIRECT, B: 1},
+	}}
+	_, err = sim.AddWarrior(splitImp)
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(1, Address(50+r.Intn(100))))
+	return sim
+}
+
+func requireSameState(t *testing.T, a, b Simulator) {
+	require.Equal(t, a.CycleCount(), b.CycleCount())
+	for i := Address(0); i < a.CoreSize(); i++ {
+		require.Equal(t, a.GetMem(i), b.GetMem(i))
+	}
+	for i := 0; i < 2; i++ {
+		require.Equal(t, a.GetWarrior(i).Alive(), b.GetWarrior(i).Alive())
+		require.Equal(t, a.GetWarrior(i).Queue(), b.GetWarrior(i).Queue())
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	for _, newSim := range []func(SimulatorConfig) (Simulator, error){NewSimulator, NewFastSimulator} {
+		sim := newSplitImpBattle(t, newSim, 3)
+		for i := 0; i < 50; i++ {
+			sim.RunCycle()
+		}
+		snap := sim.Snapshot()
+
+		for i := 0; i < 50; i++ {
+			sim.RunCycle()
+		}
+		after := sim.Snapshot()
+
+		require.NoError(t, sim.Restore(snap))
+		require.Equal(t, 50, sim.CycleCount())
+		for i := 0; i < 50; i++ {
+			sim.RunCycle()
+		}
+		require.Equal(t, after, sim.Snapshot())
+	}
+}
+
+func TestSnapshotRestoreMismatch(t *testing.T) {
+	sim := newSplitImpBattle(t, NewSimulator, 1)
+	snap := sim.Snapshot()
+
+	other, err := NewSimulator(NewQuickConfig(ICWS94, 400, 64, 2000, 20))
+	require.NoError(t, err)
+	require.Error(t, other.Restore(snap))
+}
+
+func TestSnapshotRestoreNewSimulator(t *testing.T) {
+	sim := newSplitImpBattle(t, NewSimulator, 4)
+	for i := 0; i < 20; i++ {
+		sim.RunCycle()
+	}
+
+	fresh, err := NewFastSimulator(NewQuickConfig(ICWS94, 200, 64, 2000, 20))
+	require.NoError(t, err)
+	require.NoError(t, fresh.Restore(sim.Snapshot()))
+	requireSameState(t, sim, fresh)
+}
+
+func TestClone(t *testing.T) {
+	for _, newSim := range []func(SimulatorConfig) (Simulator, error){NewSimulator, NewFastSimulator} {
+		sim := newSplitImpBattle(t, newSim, 5)
+		for i := 0; i < 30; i++ {
+			sim.RunCycle()
+		}
+
+		clone := sim.Clone()
+		requireSameState(t, sim, clone)
+
+		for i := 0; i < 30; i++ {
+			clone.RunCycle()
+		}
+		require.Equal(t, 30, sim.CycleCount())
+		require.Equal(t, 60, clone.CycleCount())
+
+		for i := 0; i < 30; i++ {
+			sim.RunCycle()
+		}
+		requireSameState(t, sim, clone)
+	}
+}
+
+func TestSnapshotEncoding(t *testing.T) {
+	sim := newSplitImpBattle(t, NewSimulator, 6)
+	for i := 0; i < 40; i++ {
+		sim.RunCycle()
+	}
+	snap := sim.Snapshot()
+
+	data, err := snap.MarshalBinary()
+	require.NoError(t, err)
+
+	decoded := &Snapshot{}
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, snap, decoded)
+}
+
+func TestSnapshotEncodingInvalid(t *testing.T) {
+	snap := &Snapshot{}
+	require.Error(t, snap.UnmarshalBinary([]byte("GMSX")))
+
+	// unknown future version
+	require.Error(t, snap.UnmarshalBinary([]byte{'G', 'M', 'S', 'S', SnapshotVersion + 1}))
+
+	sim := newSplitImpBattle(t, NewSimulator, 7)
+	data, err := sim.Snapshot().MarshalBinary()
+	require.NoError(t, err)
+	require.Error(t, snap.UnmarshalBinary(data[:len(data)/2]))
+}
+
+func TestSnapshotDecodeVersion1(t *testing.T) {
+	data := []byte{'G', 'M', 'S', 'S', 1,
+		// config
+		2, 10, 8, 100, 10, 10, 2, 2,
+		// cycle, warrior index, memory length
+		7, 0, 0,
+		// one warrior: name, author, strategy, start, code length, state,
+		// queue and p-space
+		1, 0, 0, 0, 0, 0, byte(WarriorAlive), 1, 5, 0,
+	}
+
+	snap := &Snapshot{}
+	require.NoError(t, snap.UnmarshalBinary(data))
+	require.Equal(t, Address(7), snap.Cycle)
+	require.Len(t, snap.Warriors, 1)
+	require.Equal(t, []Address{5}, snap.Warriors[0].Queue)
+	require.Equal(t, -1, snap.Warriors[0].DeathCycle)
+	require.Equal(t, Address(1), snap.Warriors[0].PeakProcesses)
+}
+
+func TestSnapshotDecodeVersion2(t *testing.T) {
+	data := []byte{'G', 'M', 'S', 'S', 2,
+		// config
+		2, 10, 8, 100, 10, 10, 2, 2,
+		// cycle, warrior index, memory length
+		7, 0, 0,
+		// one warrior: name, author, strategy, start, code length, state,
+		// queue, death cycle + 1, peak processes and p-space
+		1, 0, 0, 0, 0, 0, byte(WarriorDead), 0, 5, 3, 0,
+	}
+
+	snap := &Snapshot{}
+	require.NoError(t, snap.UnmarshalBinary(data))
+	require.Equal(t, Address(7), snap.Cycle)
+	require.Len(t, snap.Warriors, 1)
+	w := snap.Warriors[0]
+	require.Empty(t, w.Queue)
+	require.Equal(t, 4, w.DeathCycle)
+	require.Equal(t, Address(3), w.PeakProcesses)
+	require.Equal(t, QueueDropNew, snap.Config.QueuePolicy)
+}
+
+func TestSnapshotDecodeVersion3(t *testing.T) {
+	data := []byte{'G', 'M', 'S', 'S', 3,
+		// config with queue policy
+		2, 10, 8, 100, 10, 10, 2, 2, byte(QueueDropOldest),
+		// cycle, warrior index, memory length
+		7, 0, 0,
+		// one warrior: name, author, strategy, start, code length, state,
+		// queue, death cycle + 1, peak processes, dropped and p-space
+		1, 0, 0, 0, 0, 0, byte(WarriorAlive), 1, 5, 0, 8, 2, 0,
+	}
+
+	snap := &Snapshot{}
+	require.NoError(t, snap.UnmarshalBinary(data))
+	require.Equal(t, QueueDropOldest, snap.Config.QueuePolicy)
+	require.Len(t, snap.Warriors, 1)
+	w := snap.Warriors[0]
+	require.Equal(t, []Address{5}, w.Queue)
+	require.Equal(t, -1, w.DeathCycle)
+	require.Equal(t, Address(8), w.PeakProcesses)
+	require.Equal(t, Address(2), w.Dropped)
+	require.Equal(t, CauseNone, w.DeathCause)
+}
+
+func TestSnapshotDecodeVersion4(t *testing.T) {
+	data := []byte{'G', 'M', 'S', 'S', 4,
+		// config with queue policy
+		2, 10, 8, 100, 10, 10, 2, 2, byte(QueueDropNew),
+		// cycle, warrior index, memory length
+		7, 0, 0,
+		// one warrior: name, author, strategy, start, code length, state,
+		// queue, death cycle + 1, peak processes, dropped, death cause and
+		// p-space
+		1, 0, 0, 0, 0, 0, byte(WarriorDead), 0, 6, 1, 0, byte(CauseDivZero), 0,
+	}
+
+	snap := &Snapshot{}
+	require.NoError(t, snap.UnmarshalBinary(data))
+	require.Len(t, snap.Warriors, 1)
+	w := snap.Warriors[0]
+	require.Equal(t, 5, w.DeathCycle)
+	require.Equal(t, Address(1), w.PeakProcesses)
+	require.Equal(t, CauseDivZero, w.DeathCause)
+	require.Nil(t, snap.Config.Opcodes)
+}
+
+func TestSnapshotDecodeVersion5(t *testing.T) {
+	data := []byte{'G', 'M', 'S', 'S', 5,
+		// config with queue policy and one op code extension
+		2, 10, 8, 100, 10, 10, 2, 2, byte(QueueDropNew), 1, 3, 'X', 'C', 'H',
+		// cycle, warrior index, memory length
+		7, 0, 0,
+		// one warrior: name, author, strategy, start, code length, state,
+		// queue, death cycle + 1, peak processes, dropped, death cause and
+		// p-space
+		1, 0, 0, 0, 0, 0, byte(WarriorAlive), 1, 5, 0, 1, 0, byte(CauseNone), 0,
+	}
+
+	snap := &Snapshot{}
+	require.NoError(t, snap.UnmarshalBinary(data))
+	require.Equal(t, []string{"XCH"}, snap.Config.Opcodes)
+	require.Equal(t, CoreFillZero, snap.Config.CoreFill)
+	require.Len(t, snap.Warriors, 1)
+	require.Equal(t, []Address{5}, snap.Warriors[0].Queue)
+	require.Equal(t, -1, snap.Warriors[0].DeathCycle)
+}
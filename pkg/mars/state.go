@@ -0,0 +1,277 @@
+package mars
+
+import "fmt"
+
+// simState holds the core memory, warriors and counters shared by the
+// Simulator implementations. The implementations embed it and provide their
+// own exec and RunCycle methods.
+type simState struct {
+	config     SimulatorConfig
+	m          Address
+	maxProcs   Address
+	maxCycles  Address
+	readLimit  Address
+	writeLimit Address
+	m32        uint32
+	mem        []cell
+	legacy     bool
+
+	// op code extensions enabled by the config
+	opcodes opcodeSet
+
+	// initial core contents for random and image fills
+	fill []cell
+
+	warriors     []*warrior
+	warriorIndex int
+	warriorCount int
+
+	cycleCount Address
+
+	journal *undoJournal
+}
+
+func newSimState(config SimulatorConfig) (simState, error) {
+	err := config.Validate()
+	if err != nil {
+		return simState{}, err
+	}
+
+	s := simState{
+		config:     config,
+		m:          Address(config.CoreSize),
+		maxProcs:   Address(config.Processes),
+		maxCycles:  Address(config.Cycles),
+		readLimit:  Address(config.ReadLimit),
+		writeLimit: Address(config.WriteLimit),
+		legacy:     config.Mode == ICWS88,
+	}
+	s.m32 = uint32(s.m)
+	s.mem = make([]cell, s.m)
+
+	s.opcodes, err = newOpcodeSet(config.Opcodes)
+	if err != nil {
+		return simState{}, err
+	}
+
+	s.fill, err = newCoreFill(config, s.opcodes)
+	if err != nil {
+		return simState{}, err
+	}
+	s.fillCore()
+
+	return s, nil
+}
+
+func (s *simState) CoreSize() Address {
+	return s.m
+}
+
+func (s *simState) CycleCount() int {
+	return int(s.cycleCount)
+}
+
+func (s *simState) addressSigned(a Address) int {
+	if a > (s.m / 2) {
+		return -(int(s.m) - int(a))
+	}
+	return int(a)
+}
+
+func (s *simState) GetWarrior(i int) Warrior {
+	if i < 0 || i >= s.warriorCount {
+		return nil
+	}
+	return s.warriors[i]
+}
+
+func (s *simState) AddWarrior(data *WarriorData) (Warrior, error) {
+	return s.addWarrior(data)
+}
+
+func (s *simState) addWarrior(data *WarriorData) (*warrior, error) {
+	w := &warrior{
+		data:       data.Copy(),
+		sim:        s,
+		deathCycle: -1,
+	}
+	w.index = len(s.warriors)
+	s.warriors = append(s.warriors, w)
+	s.warriorCount += 1
+	w.state = WarriorAdded
+
+	return w, nil
+}
+
+func (s *simState) spawnWarrior(wi int, startOffset Address) (*warrior, error) {
+	if wi > s.warriorCount {
+		return nil, fmt.Errorf("warrior index out of bounds")
+	}
+	w := s.warriors[wi]
+
+	for i := Address(0); i < Address(len(w.data.Code)); i++ {
+		inst := w.data.Code[i]
+		inst.A %= s.m
+		inst.B %= s.m
+		s.mem[(startOffset+i)%s.m] = newCell(inst)
+	}
+
+	if w.pq == nil {
+		w.pq = newProcessQueue(s.maxProcs, s.config.QueuePolicy)
+	} else {
+		w.pq.reset()
+	}
+	w.pq.Push((startOffset + Address(w.data.Start)) % s.m)
+	w.state = WarriorAlive
+	w.deathCycle = -1
+	w.peakProcs = 1
+
+	return w, nil
+}
+
+// popTask finds the next living warrior, starting at s.warriorIndex, and
+// pops the next process from its queue. It returns false if no living
+// warriors are found.
+func (s *simState) popTask() (*warrior, Address, bool) {
+	for i := 0; ; i++ {
+		s.warriorIndex = (s.warriorIndex + i) % s.warriorCount
+		if s.warriors[s.warriorIndex].state == WarriorAlive {
+			warrior := s.warriors[s.warriorIndex]
+
+			// I don't like this, and this should never happen, but we will
+			// silently reap any zombie warriors here that are 'alive' without
+			// a process queue so we can continue and check the next ones.
+			pc, err := warrior.pq.Pop()
+			if err != nil {
+				warrior.state = WarriorDead
+				warrior.deathCycle = int(s.cycleCount)
+				warrior.deathCause = CauseNone
+				continue
+			}
+
+			return warrior, pc, true
+		}
+		if i == s.warriorCount {
+			return nil, 0, false
+		}
+	}
+}
+
+// endTask updates the process count statistics of w after executing IR and
+// marks it dead if it has no processes left. It returns true if w died.
+func (s *simState) endTask(w *warrior, IR cell) bool {
+	n := w.pq.Len()
+	if n > w.peakProcs {
+		w.peakProcs = n
+	}
+	if n == 0 {
+		w.state = WarriorDead
+		w.deathCycle = int(s.cycleCount)
+		w.deathCause = s.terminateCause(IR)
+		return true
+	}
+	return false
+}
+
+// endCycle advances the warrior index and cycle counter and returns the
+// number of living warriors
+func (s *simState) endCycle() int {
+	s.warriorIndex = (s.warriorIndex + 1) % s.warriorCount
+	s.cycleCount++
+
+	nAlive := 0
+	for i := 0; i < s.warriorCount; i++ {
+		if s.warriors[i].state == WarriorAlive {
+			nAlive += 1
+		}
+	}
+
+	return nAlive
+}
+
+func (s *simState) readFold(pointer Address) Address {
+	res := pointer % s.readLimit
+	if res > (s.readLimit / 2) {
+		res += (s.m - s.readLimit)
+	}
+	return res
+}
+
+func (s *simState) writeFold(pointer Address) Address {
+	res := pointer % s.writeLimit
+	if res > (s.writeLimit / 2) {
+		res += (s.m - s.writeLimit)
+	}
+	return res
+}
+
+// run calls runCycle until the max cycles are reached, one warrior remains
+// in a battle with more than one warrior, or the only warrior dies in a
+// single warrior battle
+func (s *simState) run(runCycle func() int) BattleResult {
+	nWarriors := len(s.warriors)
+
+	// if no warriors are loaded, return an empty result
+	if nWarriors == 0 {
+		return s.Result()
+	}
+
+	// run until simulation
+	for s.cycleCount < s.maxCycles {
+		aliveCount := runCycle()
+
+		if nWarriors == 1 && aliveCount == 0 {
+			break
+		} else if nWarriors > 1 && aliveCount == 1 {
+			break
+		}
+	}
+
+	return s.Result()
+}
+
+func (s *simState) GetMem(a Address) Instruction {
+	return s.mem[a%s.m].instruction()
+}
+
+func (s *simState) Snapshot() *Snapshot {
+	return s.snapshot()
+}
+
+// reset refills the core and clears the counters and process queues in place, keeping the
+// loaded warriors so they can be spawned again
+func (s *simState) reset() {
+	if s.journal != nil {
+		s.journal.clear()
+	}
+	for _, w := range s.warriors {
+		w.state = WarriorAdded
+		w.deathCycle = -1
+		w.deathCause = CauseNone
+		w.peakProcs = 0
+		if w.pq != nil {
+			w.pq.reset()
+		}
+	}
+	s.fillCore()
+	s.cycleCount = 0
+	s.warriorIndex = 0
+}
+
+// runRound resets the simulator, spawns each warrior at the matching
+// offset, runs the battle and stores the result in r
+func (s *simState) runRound(reset func(), spawn func(int, Address) error, runCycle func() int, offsets []Address, r *BattleResult) error {
+	if len(offsets) != len(s.warriors) {
+		return fmt.Errorf("expected %d offsets, got %d", len(s.warriors), len(offsets))
+	}
+
+	reset()
+	for i, offset := range offsets {
+		if err := spawn(i, offset); err != nil {
+			return err
+		}
+	}
+	s.runUntil(runCycle, func() (StopReason, bool) { return 0, false })
+	s.ResultInto(r)
+	return nil
+}
@@ -0,0 +1,89 @@
+package mars
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRoundSim(t testing.TB, newSim func(SimulatorConfig) (Simulator, error)) Simulator {
+	config := ConfigNOP94()
+	impdata, err := ParseLoadFile(strings.NewReader(imp94), config)
+	require.NoError(t, err)
+
+	sim, err := newSim(config)
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(makeDwarfData())
+	require.NoError(t, err)
+	_, err = sim.AddWarrior(&impdata)
+	require.NoError(t, err)
+	return sim
+}
+
+func TestReset(t *testing.T) {
+	for _, newSim := range []func(SimulatorConfig) (Simulator, error){NewSimulator, NewFastSimulator} {
+		sim := newRoundSim(t, newSim)
+		require.NoError(t, sim.SpawnWarrior(0, 0))
+		require.NoError(t, sim.SpawnWarrior(1, 4000))
+		sim.RunCycles(100)
+
+		sim.Reset()
+		require.Equal(t, 0, sim.CycleCount())
+		for a := Address(0); a < sim.CoreSize(); a++ {
+			require.Equal(t, Instruction{}, sim.GetMem(a))
+		}
+		for i := 0; i < 2; i++ {
+			w := sim.GetWarrior(i)
+			require.False(t, w.Alive())
+			require.Equal(t, Address(0), w.ThreadCount())
+		}
+
+		snap := sim.Snapshot()
+		require.Equal(t, Address(0), snap.Cycle)
+		require.Equal(t, 0, snap.WarriorIndex)
+	}
+}
+
+func TestRunRoundMatchesNewSimulator(t *testing.T) {
+	for _, newSim := range []func(SimulatorConfig) (Simulator, error){NewSimulator, NewFastSimulator} {
+		sim := newRoundSim(t, newSim)
+		result := BattleResult{}
+		for _, offset := range []Address{4000, 1234, 6000} {
+			require.NoError(t, sim.RunRound([]Address{0, offset}, &result))
+
+			fresh := newRoundSim(t, newSim)
+			require.NoError(t, fresh.SpawnWarrior(0, 0))
+			require.NoError(t, fresh.SpawnWarrior(1, offset))
+			require.Equal(t, fresh.Run(), result)
+			require.Equal(t, fresh.Snapshot(), sim.Snapshot())
+		}
+
+		require.Error(t, sim.RunRound([]Address{0}, &result))
+	}
+}
+
+func TestRunRoundAllocations(t *testing.T) {
+	sim := newRoundSim(t, NewFastSimulator)
+	result := BattleResult{}
+	offsets := []Address{0, 4000}
+	require.NoError(t, sim.RunRound(offsets, &result))
+
+	allocs := testing.AllocsPerRun(10, func() {
+		offsets[1] = (offsets[1] + 100) % 7800
+		sim.RunRound(offsets, &result)
+	})
+	require.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkFastSimRunRound(b *testing.B) {
+	sim := newRoundSim(b, NewFastSimulator)
+	result := BattleResult{}
+	offsets := []Address{0, 4000}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sim.RunRound(offsets, &result)
+	}
+}
@@ -39,8 +39,10 @@ func TestStateRecorderDwarf(t *testing.T) {
 				-1, -1, -1, 0,
 				-1, -1, -1, 0,
 			},
+			// the mov of the last loop reads address 3 as its source and
+			// B pointer after the add writes it
 			state: []CoreState{
-				CoreExecuted, CoreExecuted, CoreExecuted, CoreWritten,
+				CoreExecuted, CoreExecuted, CoreExecuted, CoreRead,
 				CoreEmpty, CoreEmpty, CoreEmpty, CoreWritten,
 				CoreEmpty, CoreEmpty, CoreEmpty, CoreWritten,
 				CoreEmpty, CoreEmpty, CoreEmpty, CoreWritten,
@@ -52,6 +54,25 @@ func TestStateRecorderDwarf(t *testing.T) {
 	runStateRecorderTests(t, "dwarf recorder", tests)
 }
 
+func TestStateRecorderRestore(t *testing.T) {
+	sim, err := NewReportingSimulator(ConfigNOP94())
+	require.NoError(t, err)
+	recorder := NewStateRecorder(sim)
+	sim.AddReporter(recorder)
+	_, err = sim.AddWarrior(makeDwarfData())
+	require.NoError(t, err)
+	require.NoError(t, sim.SpawnWarrior(0, 0))
+
+	snap := sim.Snapshot()
+	sim.RunCycle()
+	require.NoError(t, sim.Restore(snap))
+
+	// restoring a snapshot keeps the recorded accesses
+	state, color := recorder.GetMemState(0)
+	require.Equal(t, CoreExecuted, state)
+	require.Equal(t, 0, color)
+}
+
 func runStateRecorderTests(t *testing.T, set_name string, tests []recorderTest) {
 	for i, test := range tests {
 		coresize := test.coresize
@@ -24,6 +24,7 @@ type Warrior interface {
 	Author() string
 	Length() int
 	Queue() []Address
+	ThreadCount() Address
 }
 
 // Copy creates a deep copy of a WarriorData object
@@ -42,11 +43,16 @@ func (w *WarriorData) Copy() *WarriorData {
 // warrior is a manifestation WarriorData in a Simulator
 type warrior struct {
 	data  *WarriorData
-	sim   *reportSim
+	sim   *simState
 	index int
 	pq    *processQueue
 	// pspace []Instruction
 	state WarriorState
+
+	// deathCycle is the cycle the warrior died on, or -1
+	deathCycle int
+	deathCause TerminateCause
+	peakProcs  Address
 }
 
 // Name returns the Warrior's Name
@@ -73,10 +79,23 @@ func (w *warrior) Alive() bool {
 	return w.state == WarriorAlive
 }
 
+// ThreadCount returns the number of processes in the Warrior's queue
 func (w *warrior) ThreadCount() Address {
+	if w.pq == nil {
+		return 0
+	}
 	return w.pq.Len()
 }
 
+// dropped returns the number of processes discarded from the Warrior's full
+// queue
+func (w *warrior) dropped() Address {
+	if w.pq == nil {
+		return 0
+	}
+	return w.pq.dropped
+}
+
 func (w *warrior) LoadCode() string {
 	out := ""
 
@@ -0,0 +1,130 @@
+// Package posmap summarizes the results of a two warrior match by the
+// starting offset of the second warrior, and writes them as CSV or as a
+// strip image where each column is one offset.
+package posmap
+
+import (
+	"encoding/csv"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/bobertlo/gmars/pkg/mars"
+)
+
+// Cell holds the results of the rounds played with the second warrior at
+// one offset, from the point of view of the first warrior
+type Cell struct {
+	Offset mars.Address
+	Wins   int
+	Losses int
+	Ties   int
+}
+
+// Outcome returns "win", "loss" or "tie" if every round at the offset had
+// the same outcome, or "mixed" otherwise
+func (c Cell) Outcome() string {
+	switch {
+	case c.Losses == 0 && c.Ties == 0:
+		return "win"
+	case c.Wins == 0 && c.Ties == 0:
+		return "loss"
+	case c.Wins == 0 && c.Losses == 0:
+		return "tie"
+	default:
+		return "mixed"
+	}
+}
+
+// Summarize merges the results of rounds played at the same offset and
+// returns one cell per offset, ordered by offset
+func Summarize(positions []mars.PositionResult) []Cell {
+	index := make(map[mars.Address]int)
+	var cells []Cell
+	for _, p := range positions {
+		i, ok := index[p.Offset]
+		if !ok {
+			i = len(cells)
+			index[p.Offset] = i
+			cells = append(cells, Cell{Offset: p.Offset})
+		}
+
+		switch {
+		case p.Tie:
+			cells[i].Ties++
+		case p.Winner == 0:
+			cells[i].Wins++
+		default:
+			cells[i].Losses++
+		}
+	}
+
+	sort.Slice(cells, func(a, b int) bool {
+		return cells[a].Offset < cells[b].Offset
+	})
+	return cells
+}
+
+// WriteCSV writes one row per cell with a header row
+func WriteCSV(w io.Writer, cells []Cell) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"offset", "outcome", "wins", "losses", "ties"})
+	for _, c := range cells {
+		cw.Write([]string{
+			strconv.FormatUint(uint64(c.Offset), 10),
+			c.Outcome(),
+			strconv.Itoa(c.Wins),
+			strconv.Itoa(c.Losses),
+			strconv.Itoa(c.Ties),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var (
+	// WinColor marks offsets won by the first warrior
+	WinColor = color.RGBA{0x2e, 0xa0, 0x43, 0xff}
+	// LossColor marks offsets lost by the first warrior
+	LossColor = color.RGBA{0xd0, 0x3a, 0x2f, 0xff}
+	// TieColor marks offsets where both warriors survived
+	TieColor = color.RGBA{0xa0, 0xa0, 0xa0, 0xff}
+)
+
+// color blends the outcome colors by the share of rounds with each outcome
+func (c Cell) color() color.RGBA {
+	total := c.Wins + c.Losses + c.Ties
+	if total == 0 {
+		return color.RGBA{A: 0xff}
+	}
+	blend := func(w, l, t uint8) uint8 {
+		return uint8((int(w)*c.Wins + int(l)*c.Losses + int(t)*c.Ties) / total)
+	}
+	return color.RGBA{
+		R: blend(WinColor.R, LossColor.R, TieColor.R),
+		G: blend(WinColor.G, LossColor.G, TieColor.G),
+		B: blend(WinColor.B, LossColor.B, TieColor.B),
+		A: 0xff,
+	}
+}
+
+// Render draws the cells as a strip with one column of the given height
+// per cell. Mixed cells blend the colors of their outcomes.
+func Render(cells []Cell, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(cells), height))
+	for x, c := range cells {
+		col := c.color()
+		for y := 0; y < height; y++ {
+			img.SetRGBA(x, y, col)
+		}
+	}
+	return img
+}
+
+// WritePNG renders the cells and writes them as a PNG image
+func WritePNG(w io.Writer, cells []Cell, height int) error {
+	return png.Encode(w, Render(cells, height))
+}
@@ -0,0 +1,66 @@
+package posmap
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/stretchr/testify/require"
+)
+
+func testPositions() []mars.PositionResult {
+	return []mars.PositionResult{
+		{Offset: 300, Winner: 1},
+		{Offset: 100, Winner: 0},
+		{Offset: 200, Winner: -1, Tie: true},
+		{Offset: 300, Winner: 0},
+		{Offset: 100, Winner: 0},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	cells := Summarize(testPositions())
+	require.Equal(t, []Cell{
+		{Offset: 100, Wins: 2},
+		{Offset: 200, Ties: 1},
+		{Offset: 300, Wins: 1, Losses: 1},
+	}, cells)
+
+	require.Equal(t, "win", cells[0].Outcome())
+	require.Equal(t, "tie", cells[1].Outcome())
+	require.Equal(t, "mixed", cells[2].Outcome())
+	require.Equal(t, "loss", Cell{Losses: 3}.Outcome())
+	require.Empty(t, Summarize(nil))
+}
+
+func TestWriteCSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteCSV(buf, Summarize(testPositions())))
+	require.Equal(t, strings.Join([]string{
+		"offset,outcome,wins,losses,ties",
+		"100,win,2,0,0",
+		"200,tie,0,0,1",
+		"300,mixed,1,1,0",
+		"",
+	}, "\n"), buf.String())
+}
+
+func TestRender(t *testing.T) {
+	cells := Summarize(testPositions())
+	img := Render(cells, 4)
+	require.Equal(t, 3, img.Bounds().Dx())
+	require.Equal(t, 4, img.Bounds().Dy())
+	require.Equal(t, WinColor, img.RGBAAt(0, 3))
+	require.Equal(t, TieColor, img.RGBAAt(1, 0))
+
+	mixed := img.RGBAAt(2, 0)
+	require.Equal(t, uint8((int(WinColor.R)+int(LossColor.R))/2), mixed.R)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WritePNG(buf, cells, 4))
+	decoded, err := png.Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, img.Bounds(), decoded.Bounds())
+}
@@ -0,0 +1,114 @@
+package rating
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Entry is a row of the leaderboard
+type Entry struct {
+	Rank   int
+	Name   string
+	Player Player
+}
+
+// Leaderboard returns the players ordered by rating, with ties ordered by
+// name
+func (t *Table) Leaderboard() []Entry {
+	out := make([]Entry, 0, len(t.Players))
+	for name, p := range t.Players {
+		out = append(out, Entry{Name: name, Player: *p})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Player.Rating != out[j].Player.Rating {
+			return out[i].Player.Rating > out[j].Player.Rating
+		}
+		return out[i].Name < out[j].Name
+	})
+	for i := range out {
+		out[i].Rank = i + 1
+	}
+	return out
+}
+
+// WriteLeaderboard writes the leaderboard as text. Glicko-2 ratings are
+// shown with their deviation.
+func (t *Table) WriteLeaderboard(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	entries := t.Leaderboard()
+	width := len("Name")
+	for _, e := range entries {
+		width = max(width, len(e.Name))
+	}
+
+	if t.System == Glicko2 {
+		fmt.Fprintf(bw, "%4s  %-*s  %7s  %6s  %7s\n", "Rank", width, "Name", "Rating", "RD", "Matches")
+		for _, e := range entries {
+			fmt.Fprintf(bw, "%4d  %-*s  %7.1f  %6.1f  %7d\n", e.Rank, width, e.Name, e.Player.Rating, e.Player.Deviation, e.Player.Matches)
+		}
+	} else {
+		fmt.Fprintf(bw, "%4s  %-*s  %7s  %7s\n", "Rank", width, "Name", "Rating", "Matches")
+		for _, e := range entries {
+			fmt.Fprintf(bw, "%4d  %-*s  %7.1f  %7d\n", e.Rank, width, e.Name, e.Player.Rating, e.Player.Matches)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Suggestion is a pairing worth playing next
+type Suggestion struct {
+	A, B string
+
+	// Expected is the expected score of A against B
+	Expected float64
+
+	// Information estimates how much the result would change the ratings
+	Information float64
+}
+
+// uncertainty returns the relative uncertainty of a rating: the Glicko-2
+// deviation, or for Elo a value shrinking with the number of matches
+func (t *Table) uncertainty(p *Player) float64 {
+	if t.System == Glicko2 {
+		return p.Deviation / DefaultDeviation
+	}
+	return 1 / math.Sqrt(float64(1+p.Matches))
+}
+
+// Suggest returns up to n pairings whose results are least predictable,
+// preferring players with uncertain ratings. Pairings are ordered by
+// decreasing information.
+func (t *Table) Suggest(n int) []Suggestion {
+	names := make([]string, 0, len(t.Players))
+	for name := range t.Players {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []Suggestion
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			pa, pb := t.Players[a], t.Players[b]
+			e := t.expected(pa, pb)
+			out = append(out, Suggestion{
+				A:           a,
+				B:           b,
+				Expected:    e,
+				Information: e * (1 - e) * (t.uncertainty(pa) + t.uncertainty(pb)),
+			})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Information > out[j].Information
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
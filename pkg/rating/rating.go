@@ -0,0 +1,335 @@
+// Package rating keeps Elo or Glicko-2 ratings of warriors from the results
+// of matches, for hills where every pairing cannot be played.
+//
+// Each match is treated as a single game scored by the share of rounds won,
+// with ties counting half: a warrior winning 60 rounds, losing 20 and tying
+// 20 scores 0.7.
+package rating
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bobertlo/gmars/pkg/tournament"
+)
+
+// System selects the rating formula
+type System uint8
+
+const (
+	Elo System = iota
+	Glicko2
+)
+
+var systemNames = map[System]string{
+	Elo:     "elo",
+	Glicko2: "glicko2",
+}
+
+func (s System) String() string {
+	if name, ok := systemNames[s]; ok {
+		return name
+	}
+	return "?"
+}
+
+func (s System) MarshalText() ([]byte, error) {
+	name, ok := systemNames[s]
+	if !ok {
+		return nil, fmt.Errorf("invalid rating system %d", s)
+	}
+	return []byte(name), nil
+}
+
+func (s *System) UnmarshalText(text []byte) error {
+	for system, name := range systemNames {
+		if strings.EqualFold(string(text), name) {
+			*s = system
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid rating system '%s'", text)
+}
+
+const (
+	// DefaultRating is the initial rating of a new player
+	DefaultRating = 1500
+	// DefaultDeviation is the initial Glicko-2 rating deviation
+	DefaultDeviation = 350
+	// DefaultVolatility is the initial Glicko-2 volatility
+	DefaultVolatility = 0.06
+	// DefaultK is the default Elo K factor
+	DefaultK = 32
+	// DefaultTau is the default Glicko-2 system constant
+	DefaultTau = 0.5
+
+	// glickoScale converts between Glicko and Glicko-2 scales
+	glickoScale = 173.7178
+)
+
+// Player is the rating of a warrior
+type Player struct {
+	Rating float64 `json:"rating"`
+
+	// Deviation and Volatility are only used by Glicko-2
+	Deviation  float64 `json:"deviation,omitempty"`
+	Volatility float64 `json:"volatility,omitempty"`
+
+	// Matches is the number of matches recorded
+	Matches int `json:"matches"`
+}
+
+// Table holds the ratings of a set of players
+type Table struct {
+	System System `json:"system"`
+
+	// K is the Elo K factor
+	K float64 `json:"k,omitempty"`
+
+	// Tau is the Glicko-2 system constant limiting volatility changes
+	Tau float64 `json:"tau,omitempty"`
+
+	Players map[string]*Player `json:"players"`
+}
+
+// New returns an empty table using the default constants of a system
+func New(system System) *Table {
+	t := &Table{System: system, Players: make(map[string]*Player)}
+	switch system {
+	case Elo:
+		t.K = DefaultK
+	case Glicko2:
+		t.Tau = DefaultTau
+	}
+	return t
+}
+
+// Load reads a table saved by Save. Missing constants are set to the
+// defaults of the system.
+func Load(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &Table{}
+	if err := json.NewDecoder(f).Decode(t); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	switch t.System {
+	case Elo:
+		if t.K == 0 {
+			t.K = DefaultK
+		}
+	case Glicko2:
+		if t.Tau == 0 {
+			t.Tau = DefaultTau
+		}
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if t.Players == nil {
+		t.Players = make(map[string]*Player)
+	}
+	return t, nil
+}
+
+// validate checks the system and constants of a table
+func (t *Table) validate() error {
+	switch t.System {
+	case Elo:
+		if t.K <= 0 {
+			return fmt.Errorf("invalid k %g", t.K)
+		}
+	case Glicko2:
+		if t.Tau <= 0 {
+			return fmt.Errorf("invalid tau %g", t.Tau)
+		}
+	default:
+		return fmt.Errorf("invalid rating system %d", t.System)
+	}
+	return nil
+}
+
+// Save writes a table to a JSON file, replacing it atomically
+func (t *Table) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(t); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Player returns the rating of a player, adding it with the initial rating
+// if it is not in the table
+func (t *Table) Player(name string) *Player {
+	p, ok := t.Players[name]
+	if !ok {
+		p = t.newPlayer()
+		t.Players[name] = p
+	}
+	return p
+}
+
+// lookup returns the rating of a player, or the initial rating without
+// adding the player if it is not in the table
+func (t *Table) lookup(name string) *Player {
+	if p, ok := t.Players[name]; ok {
+		return p
+	}
+	return t.newPlayer()
+}
+
+// newPlayer returns a player with the initial rating of the system
+func (t *Table) newPlayer() *Player {
+	p := &Player{Rating: DefaultRating}
+	if t.System == Glicko2 {
+		p.Deviation = DefaultDeviation
+		p.Volatility = DefaultVolatility
+	}
+	return p
+}
+
+// Record updates the ratings of two players from the result of a match
+// between them, counted from the point of view of a
+func (t *Table) Record(a, b string, wins, losses, ties int) error {
+	rounds := wins + losses + ties
+	if rounds == 0 {
+		return fmt.Errorf("no rounds in match")
+	}
+	if a == b {
+		return fmt.Errorf("cannot rate a player against itself")
+	}
+	if err := t.validate(); err != nil {
+		return err
+	}
+	s := (float64(wins) + float64(ties)/2) / float64(rounds)
+
+	pa, pb := t.Player(a), t.Player(b)
+	switch t.System {
+	case Elo:
+		ea := t.expected(pa, pb)
+		pa.Rating, pb.Rating = pa.Rating+t.K*(s-ea), pb.Rating+t.K*(ea-s)
+	case Glicko2:
+		na := t.glicko2(pa, pb, s)
+		nb := t.glicko2(pb, pa, 1-s)
+		*pa, *pb = na, nb
+	}
+	pa.Matches++
+	pb.Matches++
+	return nil
+}
+
+// RecordTournament records every pairing played in a tournament, skipping
+// self-play
+func (t *Table) RecordTournament(r *tournament.Result) error {
+	for i := range r.Matrix {
+		for j := i + 1; j < len(r.Matrix[i]); j++ {
+			p := r.Matrix[i][j]
+			if !p.Played {
+				continue
+			}
+			if err := t.Record(r.Names[i], r.Names[j], p.Wins, p.Losses, p.Ties); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Expected returns the expected score of a against b, between 0 and 1.
+// Players not in the table have the initial rating and are not added.
+func (t *Table) Expected(a, b string) float64 {
+	return t.expected(t.lookup(a), t.lookup(b))
+}
+
+func (t *Table) expected(a, b *Player) float64 {
+	if t.System == Glicko2 {
+		mu, muj := (a.Rating-DefaultRating)/glickoScale, (b.Rating-DefaultRating)/glickoScale
+		return glickoE(mu, muj, b.Deviation/glickoScale)
+	}
+	return 1 / (1 + math.Pow(10, (b.Rating-a.Rating)/400))
+}
+
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func glickoE(mu, muj, phij float64) float64 {
+	return 1 / (1 + math.Exp(-glickoG(phij)*(mu-muj)))
+}
+
+// glicko2 returns the rating of p after a rating period with a single
+// game against opp with score s
+func (t *Table) glicko2(p, opp *Player, s float64) Player {
+	mu := (p.Rating - DefaultRating) / glickoScale
+	phi := p.Deviation / glickoScale
+	muj := (opp.Rating - DefaultRating) / glickoScale
+	phij := opp.Deviation / glickoScale
+
+	g := glickoG(phij)
+	e := glickoE(mu, muj, phij)
+	v := 1 / (g * g * e * (1 - e))
+	delta := v * g * (s - e)
+
+	// find the new volatility with the Illinois algorithm
+	sigma := p.Volatility
+	tau := t.Tau
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > 1e-6 {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	newSigma := math.Exp(A / 2)
+
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*g*(s-e)
+
+	return Player{
+		Rating:     newMu*glickoScale + DefaultRating,
+		Deviation:  newPhi * glickoScale,
+		Volatility: newSigma,
+		Matches:    p.Matches,
+	}
+}
@@ -0,0 +1,170 @@
+package rating
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bobertlo/gmars/pkg/tournament"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElo(t *testing.T) {
+	table := New(Elo)
+	require.InDelta(t, 0.5, table.Expected("a", "b"), 1e-9)
+	require.Empty(t, table.Players)
+
+	require.NoError(t, table.Record("a", "b", 100, 0, 0))
+	require.InDelta(t, 1516, table.Players["a"].Rating, 1e-9)
+	require.InDelta(t, 1484, table.Players["b"].Rating, 1e-9)
+	require.Equal(t, 1, table.Players["a"].Matches)
+
+	// a draw against a weaker player lowers the rating
+	require.NoError(t, table.Record("a", "b", 0, 0, 10))
+	require.Less(t, table.Players["a"].Rating, 1516.0)
+	require.InDelta(t, 3000, table.Players["a"].Rating+table.Players["b"].Rating, 1e-9)
+
+	require.Error(t, table.Record("a", "b", 0, 0, 0))
+	require.Error(t, table.Record("a", "a", 1, 0, 0))
+}
+
+func TestGlicko2(t *testing.T) {
+	// the player of the Glicko-2 paper example beating its first opponent
+	table := New(Glicko2)
+	table.Players["p"] = &Player{Rating: 1500, Deviation: 200, Volatility: 0.06}
+	table.Players["o"] = &Player{Rating: 1400, Deviation: 30, Volatility: 0.06}
+	require.NoError(t, table.Record("p", "o", 1, 0, 0))
+
+	p := table.Players["p"]
+	require.Greater(t, p.Rating, 1500.0)
+	require.Less(t, p.Deviation, 200.0)
+	require.InDelta(t, 0.06, p.Volatility, 0.001)
+	require.Less(t, table.Players["o"].Rating, 1400.0)
+
+	// a new player's deviation shrinks with each match
+	table = New(Glicko2)
+	prev := float64(DefaultDeviation)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, table.Record("a", "b", 6, 3, 1))
+		require.Less(t, table.Players["a"].Deviation, prev)
+		prev = table.Players["a"].Deviation
+	}
+	require.Greater(t, table.Players["a"].Rating, table.Players["b"].Rating)
+	require.Greater(t, table.Expected("a", "b"), 0.5)
+}
+
+func TestInvalidConstants(t *testing.T) {
+	for _, table := range []*Table{
+		{System: Elo, K: -1, Players: make(map[string]*Player)},
+		{System: Glicko2, Tau: -0.5, Players: make(map[string]*Player)},
+		{System: Glicko2, Players: make(map[string]*Player)},
+		{System: System(9), Players: make(map[string]*Player)},
+	} {
+		require.Error(t, table.Record("a", "b", 1, 0, 0))
+		require.Empty(t, table.Players)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	for _, system := range []System{Elo, Glicko2} {
+		table := New(system)
+		require.NoError(t, table.Record("a", "b", 3, 1, 1))
+		require.NoError(t, table.Record("b", "c", 2, 2, 1))
+
+		path := filepath.Join(t.TempDir(), "ratings.json")
+		require.NoError(t, table.Save(path))
+		loaded, err := Load(path)
+		require.NoError(t, err)
+		require.Equal(t, table, loaded)
+	}
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+
+	// missing constants load as the defaults
+	path := filepath.Join(t.TempDir(), "ratings.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"system": "elo", "players": {}}`), 0o644))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, New(Elo), loaded)
+	require.NoError(t, os.WriteFile(path, []byte(`{"system": "glicko2"}`), 0o644))
+	loaded, err = Load(path)
+	require.NoError(t, err)
+	require.Equal(t, New(Glicko2), loaded)
+	require.NoError(t, loaded.Record("a", "b", 1, 0, 0))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"system": "glicko2", "tau": -1}`), 0o644))
+	_, err = Load(path)
+	require.Error(t, err)
+
+	var s System
+	require.Error(t, s.UnmarshalText([]byte("trueskill")))
+	require.NoError(t, s.UnmarshalText([]byte("Glicko2")))
+	require.Equal(t, Glicko2, s)
+}
+
+func TestLeaderboard(t *testing.T) {
+	table := New(Glicko2)
+	require.NoError(t, table.Record("a", "b", 1, 9, 0))
+	require.NoError(t, table.Record("c", "a", 5, 5, 0))
+
+	entries := table.Leaderboard()
+	require.Len(t, entries, 3)
+	require.Equal(t, "b", entries[0].Name)
+	for i := 1; i < len(entries); i++ {
+		require.GreaterOrEqual(t, entries[i-1].Player.Rating, entries[i].Player.Rating)
+		require.Equal(t, i+1, entries[i].Rank)
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, table.WriteLeaderboard(buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Contains(t, lines[0], "RD")
+
+	table.System = Elo
+	buf.Reset()
+	require.NoError(t, table.WriteLeaderboard(buf))
+	require.NotContains(t, buf.String(), "RD")
+}
+
+func TestSuggest(t *testing.T) {
+	table := New(Elo)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, table.Record("strong", "weak", 10, 0, 0))
+	}
+	table.Player("new")
+
+	suggestions := table.Suggest(10)
+	require.Len(t, suggestions, 3)
+	for i := 1; i < len(suggestions); i++ {
+		require.GreaterOrEqual(t, suggestions[i-1].Information, suggestions[i].Information)
+	}
+
+	// the lopsided, well known pairing is the least informative
+	last := suggestions[2]
+	require.Equal(t, []string{"strong", "weak"}, []string{last.A, last.B})
+	require.Greater(t, last.Expected, 0.7)
+
+	require.Len(t, table.Suggest(1), 1)
+}
+
+func TestRecordTournament(t *testing.T) {
+	result := &tournament.Result{
+		Names: []string{"a", "b", "c"},
+		Matrix: [][]tournament.PairResult{
+			{{Played: true, Wins: 5}, {Played: true, Wins: 8, Losses: 2}, {}},
+			{{Played: true, Wins: 2, Losses: 8}, {}, {Played: true, Ties: 10}},
+			{{}, {Played: true, Ties: 10}, {}},
+		},
+	}
+
+	table := New(Elo)
+	require.NoError(t, table.RecordTournament(result))
+	require.Len(t, table.Players, 3)
+	require.Equal(t, 1, table.Players["a"].Matches)
+	require.Equal(t, 2, table.Players["b"].Matches)
+	require.Greater(t, table.Players["a"].Rating, float64(DefaultRating))
+}
@@ -0,0 +1,215 @@
+package score
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Formula is a pMARS style score formula evaluated with integer arithmetic
+// for each surviving warrior in a round. Formulas use the operators
+// + - * / % with parentheses, integer constants and the variables:
+//
+//	W  number of warriors in the battle
+//	S  number of warriors that survived the round
+//	L  number of warriors that died in the round (W-S)
+//	T  1 if more than one warrior survived, otherwise 0
+type Formula struct {
+	text string
+	root node
+}
+
+// node is an expression in a parsed formula
+type node struct {
+	op    byte // 0 for constants, 'v' for variables, or an operator
+	value int64
+	name  byte
+	left  *node
+	right *node
+}
+
+// ParseFormula parses a score formula
+func ParseFormula(text string) (*Formula, error) {
+	p := &formulaParser{text: text}
+	root, err := p.expr()
+	if err != nil {
+		return nil, fmt.Errorf("formula '%s': %s", text, err)
+	}
+	p.skipSpace()
+	if p.pos < len(p.text) {
+		return nil, fmt.Errorf("formula '%s': unexpected '%c'", text, p.text[p.pos])
+	}
+	return &Formula{text: text, root: *root}, nil
+}
+
+// MustParseFormula parses a score formula and panics on errors
+func MustParseFormula(text string) *Formula {
+	f, err := ParseFormula(text)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func (f *Formula) String() string {
+	return f.text
+}
+
+// Points evaluates the formula. Formulas that divide by zero earn 0 points.
+func (f *Formula) Points(warriors, survivors int) float64 {
+	v, err := f.eval(warriors, survivors)
+	if err != nil {
+		return 0
+	}
+	return float64(v)
+}
+
+func (f *Formula) eval(warriors, survivors int) (int64, error) {
+	vars := map[byte]int64{
+		'W': int64(warriors),
+		'S': int64(survivors),
+		'L': int64(warriors - survivors),
+		'T': 0,
+	}
+	if survivors > 1 {
+		vars['T'] = 1
+	}
+	return f.root.eval(vars)
+}
+
+func (n *node) eval(vars map[byte]int64) (int64, error) {
+	switch n.op {
+	case 0:
+		return n.value, nil
+	case 'v':
+		return vars[n.name], nil
+	case 'n':
+		v, err := n.left.eval(vars)
+		return -v, err
+	}
+
+	l, err := n.left.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/', '%':
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		if n.op == '/' {
+			return l / r, nil
+		}
+		return l % r, nil
+	default:
+		return 0, fmt.Errorf("invalid operator '%c'", n.op)
+	}
+}
+
+// formulaParser is a recursive descent parser for score formulas
+type formulaParser struct {
+	text string
+	pos  int
+}
+
+func (p *formulaParser) skipSpace() {
+	for p.pos < len(p.text) && (p.text[p.pos] == ' ' || p.text[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space character, or 0 at the end of the text
+func (p *formulaParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.text) {
+		return 0
+	}
+	return p.text[p.pos]
+}
+
+// expr parses terms joined by + and -
+func (p *formulaParser) expr() (*node, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '+' || c == '-'; c = p.peek() {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &node{op: c, left: left, right: right}
+	}
+	return left, nil
+}
+
+// term parses factors joined by *, / and %
+func (p *formulaParser) term() (*node, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '*' || c == '/' || c == '%'; c = p.peek() {
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = &node{op: c, left: left, right: right}
+	}
+	return left, nil
+}
+
+// factor parses constants, variables, negation and parenthesized
+// expressions
+func (p *formulaParser) factor() (*node, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of formula")
+	case c == '(':
+		p.pos++
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return n, nil
+	case c == '-':
+		p.pos++
+		n, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return &node{op: 'n', left: n}, nil
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.text) && p.text[p.pos] >= '0' && p.text[p.pos] <= '9' {
+			p.pos++
+		}
+		v, err := strconv.ParseInt(p.text[start:p.pos], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constant '%s'", p.text[start:p.pos])
+		}
+		return &node{value: v}, nil
+	case strings.IndexByte("WSLTwslt", c) >= 0:
+		p.pos++
+		return &node{op: 'v', name: c &^ 0x20}, nil
+	default:
+		return nil, fmt.Errorf("unexpected '%c'", c)
+	}
+}
@@ -0,0 +1,111 @@
+// Package score implements the scoring formulas used to turn round results
+// into points for matches, tournaments and hills.
+//
+// Points are awarded per round to each warrior alive at the end of the
+// round, depending on the number of warriors in the battle and the number
+// that survived. Warriors that died earn nothing.
+package score
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobertlo/gmars/pkg/mars"
+)
+
+// Scorer awards points to a warrior that survived a round
+type Scorer interface {
+	// Points returns the points earned by a surviving warrior in a round
+	// with the given number of warriors and survivors
+	Points(warriors, survivors int) float64
+	String() string
+}
+
+// standard awards 3 points for a win and 1 for a tie
+type standard struct{}
+
+func (standard) Points(warriors, survivors int) float64 {
+	switch {
+	case warriors > 1 && survivors == 1:
+		return 3
+	case survivors > 1:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (standard) String() string {
+	return "standard"
+}
+
+// Standard awards 3 points for a win, 1 point for a tie and none for a loss
+var Standard Scorer = standard{}
+
+// MultiWarrior is the pMARS default formula, which awards (W*W-1)/S points
+// with integer division. It matches Standard for two warriors.
+var MultiWarrior Scorer = MustParseFormula("(W*W-1)/S")
+
+// Parse returns the scorer named by spec: "standard", "multiwarrior" or a
+// formula accepted by ParseFormula. An empty spec selects Standard.
+func Parse(spec string) (Scorer, error) {
+	switch strings.ToLower(strings.TrimSpace(spec)) {
+	case "", "standard":
+		return Standard, nil
+	case "multiwarrior":
+		return MultiWarrior, nil
+	default:
+		return ParseFormula(spec)
+	}
+}
+
+// Total returns the points earned by a warrior in a battle of the given
+// number of warriors, where survived[k-1] counts the rounds the warrior
+// ended alive with k survivors
+func Total(s Scorer, warriors int, survived []int) float64 {
+	total := 0.0
+	for i, n := range survived {
+		if n != 0 {
+			total += float64(n) * s.Points(warriors, i+1)
+		}
+	}
+	return total
+}
+
+// Match returns the points earned by each warrior of a match
+func Match(s Scorer, r mars.MatchResult) []float64 {
+	out := make([]float64, len(r.Survived))
+	for i, survived := range r.Survived {
+		out[i] = Total(s, len(r.Survived), survived)
+	}
+	return out
+}
+
+// Pair returns the points earned by a warrior in a two warrior match from
+// its wins and ties
+func Pair(s Scorer, wins, ties int) float64 {
+	return Total(s, 2, []int{wins, ties})
+}
+
+// PerRound returns points scaled to 100 rounds, as shown in hill reports
+func PerRound(points float64, rounds int) float64 {
+	if rounds == 0 {
+		return 0
+	}
+	return points * 100 / float64(rounds)
+}
+
+// Validate checks that a scorer can be evaluated for battles of the given
+// number of warriors
+func Validate(s Scorer, warriors int) error {
+	f, ok := s.(*Formula)
+	if !ok {
+		return nil
+	}
+	for survivors := 1; survivors <= warriors; survivors++ {
+		if _, err := f.eval(warriors, survivors); err != nil {
+			return fmt.Errorf("formula '%s': %s", f, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package score
+
+import (
+	"testing"
+
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandard(t *testing.T) {
+	require.Equal(t, 3.0, Standard.Points(2, 1))
+	require.Equal(t, 1.0, Standard.Points(2, 2))
+	require.Equal(t, 1.0, Standard.Points(4, 3))
+	require.Equal(t, 0.0, Standard.Points(1, 1))
+	require.Equal(t, 30.0, Pair(Standard, 8, 6))
+}
+
+func TestMultiWarrior(t *testing.T) {
+	// matches the standard score for two warriors
+	for survivors := 1; survivors <= 2; survivors++ {
+		require.Equal(t, Standard.Points(2, survivors), MultiWarrior.Points(2, survivors))
+	}
+
+	require.Equal(t, 15.0, MultiWarrior.Points(4, 1))
+	require.Equal(t, 7.0, MultiWarrior.Points(4, 2))
+	require.Equal(t, 5.0, MultiWarrior.Points(4, 3))
+	require.Equal(t, 3.0, MultiWarrior.Points(4, 4))
+}
+
+func TestParseFormula(t *testing.T) {
+	tests := []struct {
+		formula  string
+		warriors int
+		alive    int
+		expected float64
+	}{
+		{"3", 2, 1, 3},
+		{"W+S", 4, 2, 6},
+		{"W - S", 4, 1, 3},
+		{"L", 5, 2, 3},
+		{"T", 2, 2, 1},
+		{"T", 2, 1, 0},
+		{"3-2*T", 2, 2, 1},
+		{"(3 - 2*T) * 10 % 7", 2, 1, 2},
+		{"-W+10", 3, 1, 7},
+		{"--2", 3, 1, 2},
+		{"w*w/s", 3, 2, 4},
+		{"100/(W-S)", 3, 3, 0},
+	}
+
+	for _, test := range tests {
+		f, err := ParseFormula(test.formula)
+		require.NoError(t, err, test.formula)
+		require.Equal(t, test.expected, f.Points(test.warriors, test.alive), test.formula)
+		require.Equal(t, test.formula, f.String())
+	}
+}
+
+func TestParseFormulaErrors(t *testing.T) {
+	for _, formula := range []string{"", "W+", "(W", "W)", "X", "WS", "3 4", "2**3", "99999999999999999999"} {
+		_, err := ParseFormula(formula)
+		require.Error(t, err, formula)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for spec, expected := range map[string]Scorer{"": Standard, "standard": Standard, "MultiWarrior": MultiWarrior} {
+		s, err := Parse(spec)
+		require.NoError(t, err)
+		require.Equal(t, expected, s)
+	}
+
+	s, err := Parse("2*S")
+	require.NoError(t, err)
+	require.Equal(t, 4.0, s.Points(2, 2))
+
+	_, err = Parse("2*")
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(Standard, 2))
+	require.NoError(t, Validate(MustParseFormula("3/S"), 2))
+	require.Error(t, Validate(MustParseFormula("3/(S-1)"), 2))
+	require.Error(t, Validate(MustParseFormula("3/(W-S)"), 2))
+	require.Panics(t, func() { MustParseFormula("(") })
+}
+
+func TestMatch(t *testing.T) {
+	r := mars.NewMatchResult(3)
+	r.Add(&mars.BattleResult{Winner: 1, Warriors: []mars.WarriorResult{{}, {Alive: true}, {}}})
+	r.Add(&mars.BattleResult{Winner: -1, Tie: true, Warriors: []mars.WarriorResult{{Alive: true}, {Alive: true}, {}}})
+	r.Add(&mars.BattleResult{Winner: -1, Tie: true, Warriors: []mars.WarriorResult{{Alive: true}, {Alive: true}, {Alive: true}}})
+
+	require.Equal(t, []float64{2, 5, 1}, Match(Standard, r))
+	require.Equal(t, []float64{4 + 2, 8 + 4 + 2, 2}, Match(MultiWarrior, r))
+
+	require.Equal(t, 150.0, PerRound(15, 10))
+	require.Equal(t, 0.0, PerRound(15, 0))
+}
@@ -0,0 +1,100 @@
+package tournament
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteText writes the ranked table followed by the win/loss/tie matrix.
+// Matrix cells are from the point of view of the warrior in the row.
+func (r *Result) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	width := 4
+	for _, name := range r.Names {
+		width = max(width, len(name))
+	}
+
+	fmt.Fprintf(bw, "%4s  %-*s  %8s  %6s  %6s  %6s\n", "Rank", width, "Name", "Score", "W", "L", "T")
+	for _, s := range r.Standings() {
+		fmt.Fprintf(bw, "%4d  %-*s  %8.2f  %6d  %6d  %6d\n", s.Rank, width, s.Name, s.Score, s.Wins, s.Losses, s.Ties)
+	}
+
+	fmt.Fprintf(bw, "\n%-*s", width, "W/L/T")
+	for j := range r.Names {
+		fmt.Fprintf(bw, "  %14d", j+1)
+	}
+	fmt.Fprintln(bw)
+	for i, name := range r.Names {
+		fmt.Fprintf(bw, "%-*s", width, name)
+		for _, p := range r.Matrix[i] {
+			cell := "-"
+			if p.Played {
+				cell = fmt.Sprintf("%d/%d/%d", p.Wins, p.Losses, p.Ties)
+			}
+			fmt.Fprintf(bw, "  %14s", cell)
+		}
+		fmt.Fprintln(bw)
+	}
+
+	return bw.Flush()
+}
+
+// WriteCSV writes the ranked table as CSV with a header row
+func (r *Result) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rank", "name", "score", "wins", "losses", "ties"})
+	for _, s := range r.Standings() {
+		cw.Write([]string{
+			strconv.Itoa(s.Rank),
+			s.Name,
+			strconv.FormatFloat(s.Score, 'f', -1, 64),
+			strconv.Itoa(s.Wins),
+			strconv.Itoa(s.Losses),
+			strconv.Itoa(s.Ties),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMatrixCSV writes the win/loss/tie matrix as CSV with one row for
+// each pairing played
+func (r *Result) WriteMatrixCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"warrior", "opponent", "wins", "losses", "ties", "score"})
+	for i, row := range r.Matrix {
+		for j, p := range row {
+			if !p.Played {
+				continue
+			}
+			cw.Write([]string{
+				r.Names[i],
+				r.Names[j],
+				strconv.Itoa(p.Wins),
+				strconv.Itoa(p.Losses),
+				strconv.Itoa(p.Ties),
+				strconv.FormatFloat(p.Score, 'f', -1, 64),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonResult is the JSON encoding of a Result, including the standings
+type jsonResult struct {
+	*Result
+	Standings []Standing `json:"standings"`
+}
+
+// WriteJSON writes the result and its standings as JSON
+func (r *Result) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonResult{Result: r, Standings: r.Standings()})
+}
@@ -0,0 +1,192 @@
+// Package tournament plays round-robin tournaments between sets of warriors
+// and reports the results as score matrices and ranked tables.
+package tournament
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/bobertlo/gmars/pkg/cache"
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/bobertlo/gmars/pkg/score"
+)
+
+// Tournament describes a round-robin tournament. Every pair of warriors is
+// played for Rounds rounds on the parallel match runner.
+type Tournament struct {
+	Config   mars.SimulatorConfig
+	Warriors []*mars.WarriorData
+	Rounds   int
+
+	// Seed selects the starting positions. Each pairing uses a seed derived
+	// from Seed, the indices of the warriors and their code, so results of a
+	// pairing do not change when warriors are added after it.
+	Seed int64
+
+	// SelfPlay also plays each warrior against itself. Self-play results
+	// are stored in the matrix but do not count towards scores.
+	SelfPlay bool
+
+	// Scorer awards points for each round, or nil for score.Standard
+	Scorer score.Scorer
+
+	// Workers is the number of simulators run in parallel, or 0 to use
+	// GOMAXPROCS
+	Workers int
+
+	// Cache stores the results of pairings already played, or nil to play
+	// every pairing
+	Cache *cache.Cache
+}
+
+// PairResult holds the results of a warrior against one opponent
+type PairResult struct {
+	Played bool `json:"played"`
+	Wins   int  `json:"wins"`
+	Losses int  `json:"losses"`
+	Ties   int  `json:"ties"`
+
+	// Score is the points earned against the opponent
+	Score float64 `json:"score"`
+}
+
+// Result holds the results of a tournament
+type Result struct {
+	Names  []string `json:"names"`
+	Rounds int      `json:"rounds"`
+
+	// Matrix[i][j] is the result of warrior i playing against warrior j
+	Matrix [][]PairResult `json:"matrix"`
+
+	// Scores is the total score of each warrior against all opponents
+	Scores []float64 `json:"scores"`
+}
+
+// Standing is a row of the ranked table of a tournament
+type Standing struct {
+	Rank   int     `json:"rank"`
+	Index  int     `json:"index"`
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`
+	Wins   int     `json:"wins"`
+	Losses int     `json:"losses"`
+	Ties   int     `json:"ties"`
+}
+
+// pairSeed returns the match seed for warrior i playing warrior j
+func (t *Tournament) pairSeed(i, j int) int64 {
+	h := fnv.New64a()
+	buf := make([]byte, 0, 64)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(i))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(j))
+	h.Write(buf)
+	for _, data := range []*mars.WarriorData{t.Warriors[i], t.Warriors[j]} {
+		buf = binary.LittleEndian.AppendUint64(buf[:0], uint64(data.Start))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(len(data.Code)))
+		h.Write(buf)
+		for _, inst := range data.Code {
+			buf = append(buf[:0], byte(inst.Op), byte(inst.OpMode), byte(inst.AMode), byte(inst.BMode))
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(inst.A))
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(inst.B))
+			h.Write(buf)
+		}
+	}
+	return t.Seed ^ int64(h.Sum64())
+}
+
+// Run plays every pairing of the tournament and returns the results
+func Run(ctx context.Context, t Tournament) (*Result, error) {
+	n := len(t.Warriors)
+	if n < 2 && !t.SelfPlay {
+		return nil, fmt.Errorf("tournament needs at least two warriors")
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no warriors in tournament")
+	}
+
+	scorer := t.Scorer
+	if scorer == nil {
+		scorer = score.Standard
+	}
+	if err := score.Validate(scorer, 2); err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Names:  make([]string, n),
+		Rounds: t.Rounds,
+		Matrix: make([][]PairResult, n),
+		Scores: make([]float64, n),
+	}
+	for i, data := range t.Warriors {
+		result.Names[i] = data.Name
+		if data.Name == "" {
+			result.Names[i] = fmt.Sprintf("warrior %d", i+1)
+		}
+		result.Matrix[i] = make([]PairResult, n)
+	}
+
+	for i := 0; i < n; i++ {
+		first := i + 1
+		if t.SelfPlay {
+			first = i
+		}
+		for j := first; j < n; j++ {
+			match := mars.Match{
+				Config:   t.Config,
+				Warriors: []*mars.WarriorData{t.Warriors[i], t.Warriors[j]},
+				Rounds:   t.Rounds,
+				Seed:     t.pairSeed(i, j),
+				Workers:  t.Workers,
+			}
+			mr, _, err := cache.RunMatch(ctx, t.Cache, match)
+			if err != nil {
+				return nil, fmt.Errorf("%s vs %s: %s", result.Names[i], result.Names[j], err)
+			}
+			points := score.Match(scorer, mr)
+			result.Matrix[i][j] = PairResult{Played: true, Wins: mr.Wins[0], Losses: mr.Losses[0], Ties: mr.Ties[0], Score: points[0]}
+			result.Matrix[j][i] = PairResult{Played: true, Wins: mr.Wins[1], Losses: mr.Losses[1], Ties: mr.Ties[1], Score: points[1]}
+			if i == j {
+				continue
+			}
+			result.Scores[i] += points[0]
+			result.Scores[j] += points[1]
+		}
+	}
+
+	return result, nil
+}
+
+// Standings returns the ranked table of the tournament, ordered by score.
+// Warriors with equal scores share a rank. Self-play results are not
+// included in the totals.
+func (r *Result) Standings() []Standing {
+	out := make([]Standing, len(r.Names))
+	for i, name := range r.Names {
+		s := Standing{Index: i, Name: name, Score: r.Scores[i]}
+		for j, p := range r.Matrix[i] {
+			if i == j {
+				continue
+			}
+			s.Wins += p.Wins
+			s.Losses += p.Losses
+			s.Ties += p.Ties
+		}
+		out[i] = s
+	}
+
+	sort.SliceStable(out, func(a, b int) bool {
+		return out[a].Score > out[b].Score
+	})
+	for i := range out {
+		if i > 0 && out[i].Score == out[i-1].Score {
+			out[i].Rank = out[i-1].Rank
+		} else {
+			out[i].Rank = i + 1
+		}
+	}
+	return out
+}
@@ -0,0 +1,222 @@
+package tournament
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bobertlo/gmars/pkg/cache"
+	"github.com/bobertlo/gmars/pkg/internal/testwarriors"
+	"github.com/bobertlo/gmars/pkg/mars"
+	"github.com/bobertlo/gmars/pkg/score"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTournament(t *testing.T) Tournament {
+	config := testwarriors.Config()
+	return Tournament{
+		Config: config,
+		Warriors: []*mars.WarriorData{
+			testwarriors.Parse(t, config, testwarriors.Imp),
+			testwarriors.Parse(t, config, testwarriors.Dwarf),
+			testwarriors.Parse(t, config, testwarriors.Sit),
+		},
+		Rounds: 20,
+		Seed:   99,
+	}
+}
+
+func TestRun(t *testing.T) {
+	tour := newTestTournament(t)
+	result, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"Imp", "Dwarf", "Sit"}, result.Names)
+	for i := range result.Matrix {
+		require.False(t, result.Matrix[i][i].Played)
+		for j := range result.Matrix[i] {
+			if i == j {
+				continue
+			}
+			p := result.Matrix[i][j]
+			require.True(t, p.Played)
+			require.Equal(t, tour.Rounds, p.Wins+p.Losses+p.Ties)
+
+			// the matrix is consistent from both points of view
+			q := result.Matrix[j][i]
+			require.Equal(t, p.Wins, q.Losses)
+			require.Equal(t, p.Ties, q.Ties)
+		}
+	}
+
+	// the imp never dies against a warrior that only jumps to itself
+	require.Equal(t, tour.Rounds, result.Matrix[0][2].Ties)
+
+	standings := result.Standings()
+	require.Len(t, standings, 3)
+	for i := 1; i < len(standings); i++ {
+		require.GreaterOrEqual(t, standings[i-1].Score, standings[i].Score)
+	}
+	for _, s := range standings {
+		require.Equal(t, result.Scores[s.Index], s.Score)
+		require.Equal(t, float64(3*s.Wins+s.Ties), s.Score)
+	}
+
+	// results do not depend on parallelism
+	tour.Workers = 1
+	serial, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+	require.Equal(t, result, serial)
+}
+
+func TestRunSelfPlay(t *testing.T) {
+	tour := newTestTournament(t)
+	tour.SelfPlay = true
+	result, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+
+	for i := range result.Matrix {
+		p := result.Matrix[i][i]
+		require.True(t, p.Played)
+		require.Equal(t, tour.Rounds, p.Wins+p.Losses+p.Ties)
+	}
+
+	tour.SelfPlay = false
+	without, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+	require.Equal(t, without.Scores, result.Scores)
+}
+
+func TestRunErrors(t *testing.T) {
+	tour := newTestTournament(t)
+	tour.Warriors = tour.Warriors[:1]
+	_, err := Run(context.Background(), tour)
+	require.Error(t, err)
+
+	tour.SelfPlay = true
+	_, err = Run(context.Background(), tour)
+	require.NoError(t, err)
+
+	tour.Warriors = nil
+	_, err = Run(context.Background(), tour)
+	require.Error(t, err)
+}
+
+func TestStandingsSharedRank(t *testing.T) {
+	result := &Result{
+		Names:  []string{"a", "b", "c"},
+		Matrix: make([][]PairResult, 3),
+		Scores: []float64{3, 6, 3},
+	}
+	for i := range result.Matrix {
+		result.Matrix[i] = make([]PairResult, 3)
+	}
+
+	standings := result.Standings()
+	require.Equal(t, []int{1, 0, 2}, []int{standings[0].Index, standings[1].Index, standings[2].Index})
+	require.Equal(t, []int{1, 2, 2}, []int{standings[0].Rank, standings[1].Rank, standings[2].Rank})
+}
+
+func TestOutput(t *testing.T) {
+	tour := newTestTournament(t)
+	result, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, result.WriteText(buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 9)
+	require.True(t, strings.HasPrefix(lines[0], "Rank"))
+
+	buf.Reset()
+	require.NoError(t, result.WriteCSV(buf))
+	require.Equal(t, 4, strings.Count(buf.String(), "\n"))
+	require.True(t, strings.HasPrefix(buf.String(), "rank,name,score,wins,losses,ties\n"))
+
+	buf.Reset()
+	require.NoError(t, result.WriteMatrixCSV(buf))
+	require.Equal(t, 7, strings.Count(buf.String(), "\n"))
+
+	buf.Reset()
+	require.NoError(t, result.WriteJSON(buf))
+	var decoded struct {
+		Result
+		Standings []Standing `json:"standings"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, *result, decoded.Result)
+	require.Equal(t, result.Standings(), decoded.Standings)
+}
+
+func TestRunScorer(t *testing.T) {
+	tour := newTestTournament(t)
+	standard, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+
+	tour.Scorer = score.MustParseFormula("10*T")
+	result, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+	for i, s := range result.Standings() {
+		require.Equal(t, float64(10*s.Ties), s.Score, i)
+	}
+	for i := range result.Matrix {
+		for j, p := range result.Matrix[i] {
+			require.Equal(t, float64(10*p.Ties), p.Score)
+			require.Equal(t, standard.Matrix[i][j].Wins, p.Wins)
+		}
+	}
+
+	tour.Scorer = score.MustParseFormula("1/(S-1)")
+	_, err = Run(context.Background(), tour)
+	require.Error(t, err)
+}
+
+func TestRunCache(t *testing.T) {
+	c, err := cache.Open(t.TempDir())
+	require.NoError(t, err)
+
+	tour := newTestTournament(t)
+	expected, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+
+	tour.Cache = c
+	cold, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+	require.Equal(t, expected, cold)
+	warm, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+	require.Equal(t, expected, warm)
+
+	// pairings keep their results when a warrior is added
+	tour.Warriors = append(tour.Warriors, testwarriors.ParseNamed(t, tour.Config, "Imp 2", testwarriors.Imp))
+	grown, err := Run(context.Background(), tour)
+	require.NoError(t, err)
+	for i := range expected.Matrix {
+		for j := range expected.Matrix[i] {
+			require.Equal(t, expected.Matrix[i][j], grown.Matrix[i][j])
+		}
+	}
+}
+
+func TestPairSeedDuplicateNames(t *testing.T) {
+	tour := newTestTournament(t)
+	for _, data := range tour.Warriors {
+		data.Name = ""
+	}
+	tour.Warriors = append(tour.Warriors, testwarriors.ParseNamed(t, tour.Config, "", testwarriors.Imp))
+
+	seeds := make(map[int64]bool)
+	for i := range tour.Warriors {
+		for j := i; j < len(tour.Warriors); j++ {
+			seeds[tour.pairSeed(i, j)] = true
+		}
+	}
+	require.Len(t, seeds, 10)
+
+	// seeds depend on the code as well as the position of a pairing
+	dwarf := tour.pairSeed(0, 1)
+	tour.Warriors[1] = testwarriors.ParseNamed(t, tour.Config, "", testwarriors.Sit)
+	require.NotEqual(t, dwarf, tour.pairSeed(0, 1))
+}